@@ -244,10 +244,100 @@ func isCheckKing(p *Position, kingSquare Square) bool {
 
 // IsCheckMate returns true is the side to move is in check and has no legal moves.
 func IsCheckMate(p *Position) bool {
-	return IsCheck(p) && len(GenerateLegalMoves(p)) == 0
+	return IsCheck(p) && !p.HasLegalMove()
 }
 
 // IsStaleMate does not check the fifty move rule. It only checks if a player is not able to move, and is not in check.
 func IsStaleMate(p *Position) bool {
-	return !IsCheck(p) && len(GenerateLegalMoves(p)) == 0
+	return !IsCheck(p) && !p.HasLegalMove()
+}
+
+// SquareSafeForKing returns true if color c's king would not be attacked on square s. The king is removed from its
+// current square before testing, so squares shadowed by the king from a sliding piece's attack are correctly reported
+// as unsafe. If s holds an enemy piece it is treated as captured.
+func (p *Position) SquareSafeForKing(c Color, s Square) bool {
+	if !isValidSquare(s) || s == NoSquare {
+		return false
+	}
+	tempPosition := *p
+	if kingSquare := findKing(&tempPosition, c); kingSquare != NoSquare {
+		tempPosition.SetPieceAt(kingSquare, NoPiece)
+	}
+	tempPosition.SetPieceAt(s, Piece{c, King})
+	tempPosition.Turn = c
+	return !IsCheck(&tempPosition)
+}
+
+// AttackedSquares returns every square attacked by color c, regardless of whose turn it is. Squares occupied by c's own
+// pieces are included when they are defended. Squares are ordered from A8 to H1 like [AllSquares].
+func (p *Position) AttackedSquares(c Color) []Square {
+	attacked := []Square{}
+	for _, square := range AllSquares {
+		if isSquareAttacked(p, square, c) {
+			attacked = append(attacked, square)
+		}
+	}
+	return attacked
+}
+
+func isSquareAttacked(p *Position, s Square, by Color) bool {
+	if by != White && by != Black {
+		return false
+	}
+	tempPosition := *p
+	tempPosition.Turn = otherColor(by)
+	return isCheckPawn(&tempPosition, s) ||
+		isCheckRookQueen(&tempPosition, s) ||
+		isCheckKnight(&tempPosition, s) ||
+		isCheckBishopQueen(&tempPosition, s) ||
+		isCheckKing(&tempPosition, s)
+}
+
+// Threats returns the squares of the pieces attacking and defending the piece on s. Attackers are the opponent's
+// pieces and defenders are the piece's own. If s is empty the attackers are the side that just moved and the
+// defenders are the side to move. A piece is counted if it could capture on s; pieces attacking through another piece
+// are not counted.
+func (p *Position) Threats(s Square) (attackers []Square, defenders []Square) {
+	if !isValidSquare(s) || s == NoSquare {
+		return []Square{}, []Square{}
+	}
+	defendingColor := p.PieceAt(s).Color
+	if defendingColor == NoColor {
+		defendingColor = p.Turn
+	}
+	return attackersOf(p, s, otherColor(defendingColor)), attackersOf(p, s, defendingColor)
+}
+
+// Checkers returns the squares of the opponent's pieces giving check to the side to move. It is empty when the side to
+// move is not in check, and has two squares in the case of a double check, where only a king move can get out of check.
+func (p *Position) Checkers() []Square {
+	kingSquare := findKing(p, p.Turn)
+	if kingSquare == NoSquare || (p.Turn != White && p.Turn != Black) {
+		return []Square{}
+	}
+	return attackersOf(p, kingSquare, otherColor(p.Turn))
+}
+
+// attackersOf returns the squares of all pieces of color by that attack s.
+func attackersOf(p *Position, s Square, by Color) []Square {
+	attackers := []Square{}
+	if by != White && by != Black {
+		return attackers
+	}
+	tempPosition := *p
+	tempPosition.Turn = by
+	tempPosition.EnPassant = NoSquare
+	tempPosition.SetPieceAt(s, Piece{otherColor(by), Pawn})
+	for index, piece := range tempPosition.Board {
+		if piece.Color != by {
+			continue
+		}
+		for _, move := range generatePieceMoves(&tempPosition, indexToSquare(index)) {
+			if move.ToSquare == s {
+				attackers = append(attackers, move.FromSquare)
+				break
+			}
+		}
+	}
+	return attackers
 }
@@ -1,6 +1,7 @@
 package chess
 
 import (
+	"slices"
 	"testing"
 )
 
@@ -276,3 +277,79 @@ func BenchmarkIsStaleMate(b *testing.B) {
 		IsStaleMate(pos)
 	}
 }
+
+func TestSquareSafeForKing(t *testing.T) {
+	pos, _ := ParseFen("4k3/8/8/8/r3K3/8/8/8 w - - 0 1")
+	if pos.SquareSafeForKing(White, F4) {
+		t.Error("incorrect result: F4 is attacked through the king's current square")
+	}
+	if !pos.SquareSafeForKing(White, E3) {
+		t.Error("incorrect result: E3 should be safe")
+	}
+	if pos.SquareSafeForKing(White, D4) {
+		t.Error("incorrect result: D4 is attacked by the rook")
+	}
+	if !pos.SquareSafeForKing(White, F5) {
+		t.Error("incorrect result: F5 should be safe")
+	}
+	if pos.SquareSafeForKing(Black, E5) {
+		t.Error("incorrect result: E5 is attacked by the white king")
+	}
+}
+
+func TestAttackedSquares(t *testing.T) {
+	pos, _ := ParseFen("7k/8/8/8/8/8/1P6/K7 w - - 0 1")
+	expected := []Square{A3, C3, A2, B2, B1}
+	if attacked := pos.AttackedSquares(White); !slices.Equal(attacked, expected) {
+		t.Errorf("incorrect result: expected %v, got %v", expected, attacked)
+	}
+	expected = []Square{G8, G7, H7}
+	if attacked := pos.AttackedSquares(Black); !slices.Equal(attacked, expected) {
+		t.Errorf("incorrect result: expected %v, got %v", expected, attacked)
+	}
+}
+
+func TestThreats(t *testing.T) {
+	pos, _ := ParseFen("3r2k1/8/8/3n4/2P5/8/1B6/3R2K1 w - - 0 1")
+	attackers, defenders := pos.Threats(D5)
+	if !slices.Equal(attackers, []Square{C4, D1}) {
+		t.Errorf("incorrect result: attackers of D5: expected [C4 D1], got %v", attackers)
+	}
+	if !slices.Equal(defenders, []Square{D8}) {
+		t.Errorf("incorrect result: defenders of D5: expected [D8], got %v", defenders)
+	}
+
+	attackers, defenders = pos.Threats(C4)
+	if !slices.Equal(attackers, []Square{}) {
+		t.Errorf("incorrect result: attackers of C4: expected [], got %v", attackers)
+	}
+	if !slices.Equal(defenders, []Square{}) {
+		t.Errorf("incorrect result: defenders of C4: expected [], got %v", defenders)
+	}
+
+	attackers, defenders = pos.Threats(E3)
+	if !slices.Equal(attackers, []Square{D5}) {
+		t.Errorf("incorrect result: attackers of empty E3: expected [D5], got %v", attackers)
+	}
+	if !slices.Equal(defenders, []Square{}) {
+		t.Errorf("incorrect result: defenders of empty E3: expected [], got %v", defenders)
+	}
+}
+
+func TestCheckers(t *testing.T) {
+	testCases := []struct {
+		fen      string
+		expected []Square
+	}{
+		{"rnbqkbnr/pppppppp/8/8/8/8/PPPPPPPP/RNBQKBNR w KQkq - 0 1", []Square{}},
+		{"8/4k3/8/6B1/8/8/8/4K3 b - - 0 1", []Square{G5}},
+		{"8/8/8/8/8/3k4/4P3/4K3 b - - 0 1", []Square{E2}},
+		{"4k3/8/5N2/8/8/8/8/4R1K1 b - - 0 1", []Square{F6, E1}},
+	}
+	for _, tc := range testCases {
+		pos, _ := ParseFen(tc.fen)
+		if checkers := pos.Checkers(); !slices.Equal(checkers, tc.expected) {
+			t.Errorf("incorrect result: input %s: expected %v, got %v", tc.fen, tc.expected, checkers)
+		}
+	}
+}
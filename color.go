@@ -1,5 +1,7 @@
 package chess
 
+import "fmt"
+
 type Color uint8
 
 const (
@@ -25,3 +27,43 @@ func (c Color) String() string {
 func isValidColor(c Color) bool {
 	return c <= 2
 }
+
+// MarshalText implements [encoding.TextMarshaler]. White is "w", Black is "b", and NoColor is "-".
+func (c Color) MarshalText() ([]byte, error) {
+	switch c {
+	case NoColor:
+		return []byte("-"), nil
+	case White:
+		return []byte("w"), nil
+	case Black:
+		return []byte("b"), nil
+	default:
+		return nil, fmt.Errorf("can't marshal invalid color %d", c)
+	}
+}
+
+// UnmarshalText implements [encoding.TextUnmarshaler]. It accepts the output of [Color.MarshalText].
+func (c *Color) UnmarshalText(text []byte) error {
+	switch string(text) {
+	case "-":
+		*c = NoColor
+	case "w":
+		*c = White
+	case "b":
+		*c = Black
+	default:
+		return fmt.Errorf("can't unmarshal color: %q", text)
+	}
+	return nil
+}
+
+func otherColor(c Color) Color {
+	switch c {
+	case White:
+		return Black
+	case Black:
+		return White
+	default:
+		return NoColor
+	}
+}
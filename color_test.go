@@ -0,0 +1,26 @@
+package chess
+
+import (
+	"testing"
+)
+
+func TestColorMarshalText(t *testing.T) {
+	expected := map[Color]string{White: "w", Black: "b", NoColor: "-"}
+	for color, str := range expected {
+		text, err := color.MarshalText()
+		if err != nil || string(text) != str {
+			t.Errorf("incorrect result: input %v: expected %s, got %s, %v", color, str, text, err)
+		}
+		var parsed Color
+		if err := parsed.UnmarshalText([]byte(str)); err != nil || parsed != color {
+			t.Errorf("incorrect result: input %s: expected %v, got %v, %v", str, color, parsed, err)
+		}
+	}
+	if _, err := Color(3).MarshalText(); err == nil {
+		t.Error("incorrect result: input 3: expected error, got nil")
+	}
+	var c Color
+	if err := c.UnmarshalText([]byte("white")); err == nil {
+		t.Error("incorrect result: input white: expected error, got nil")
+	}
+}
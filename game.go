@@ -1,11 +1,13 @@
 package chess
 
 import (
+	"bufio"
 	"errors"
 	"fmt"
 	"io"
 	"maps"
 	"slices"
+	"strconv"
 	"strings"
 	"time"
 )
@@ -22,6 +24,11 @@ type Game struct {
 	position    *Position
 	moveHistory []Move
 	tags        map[string]string
+	// resultFinal is set when the game ended off the board, so that further moves don't reset the result.
+	resultFinal bool
+	// termination is the reason the game ended, kept in sync with the Termination tag.
+	termination Termination
+	observers   []func(ply int, m Move, pos *Position)
 }
 
 type Result byte
@@ -68,6 +75,78 @@ func (r Result) String() string {
 	}
 }
 
+// Termination is the reason a game ended. It is stored in a game's Termination tag, see [Game.SetTermination].
+type Termination byte
+
+const (
+	// NoTermination means no reason has been recorded, usually because the game is still in progress.
+	NoTermination Termination = iota
+	// Normal is the pgn standard's "normal" termination, for games that ended without a more specific reason given.
+	Normal
+	Checkmate
+	Stalemate
+	Resignation
+	Timeout
+	InsufficientMaterial
+	FiftyMove
+	Repetition
+	Agreement
+	Abandoned
+	Adjudication
+	RulesInfraction
+)
+
+// terminationNames holds the name of each termination, which is also the Termination tag value written for it unless
+// the pgn standard has its own value, see [terminationTag].
+var terminationNames = map[Termination]string{
+	Normal:               "normal",
+	Checkmate:            "checkmate",
+	Stalemate:            "stalemate",
+	Resignation:          "resignation",
+	Timeout:              "timeout",
+	InsufficientMaterial: "insufficient material",
+	FiftyMove:            "fifty move",
+	Repetition:           "repetition",
+	Agreement:            "agreement",
+	Abandoned:            "abandoned",
+	Adjudication:         "adjudication",
+	RulesInfraction:      "rules infraction",
+}
+
+// timeForfeitTag is the pgn standard's Termination tag value for [Timeout].
+const timeForfeitTag = "time forfeit"
+
+// String returns the name of the termination, such as "checkmate" or "fifty move".
+func (t Termination) String() string {
+	if name, ok := terminationNames[t]; ok {
+		return name
+	}
+	return "none"
+}
+
+// terminationTag returns the Termination tag value for t. ok is false for [NoTermination] and invalid values.
+func terminationTag(t Termination) (tag string, ok bool) {
+	if t == Timeout {
+		return timeForfeitTag, true
+	}
+	tag, ok = terminationNames[t]
+	return tag, ok
+}
+
+// parseTermination returns the termination for a Termination tag value, ignoring case. [NoTermination] is returned for
+// values that don't match any termination, such as "unterminated".
+func parseTermination(tag string) Termination {
+	if strings.EqualFold(tag, timeForfeitTag) {
+		return Timeout
+	}
+	for t, name := range terminationNames {
+		if strings.EqualFold(tag, name) {
+			return t
+		}
+	}
+	return NoTermination
+}
+
 // NewGame returns a [*Game] representing the starting position for a game of chess.
 func NewGame() *Game {
 	position, _ := ParseFen(DefaultFen)
@@ -88,16 +167,125 @@ func NewGame() *Game {
 	}
 }
 
+// NewGameWithTags returns a [*Game] representing the starting position, with tags used in place of the defaults
+// provided by [NewGame]. Any tags not given keep their default values. Like [Game.SetTag], the Result, SetUp, and FEN
+// tags are ignored.
+func NewGameWithTags(tags map[string]string) *Game {
+	game := NewGame()
+	for tag, value := range tags {
+		game.SetTag(tag, value)
+	}
+	return game
+}
+
+// GameFromPositions creates a game that starts at positions[0] and passes through each following position in order. The
+// move between each pair of consecutive positions is worked out from the positions themselves, including castling,
+// en passant, and promotions. An error is returned if any position is nil, if the first position is not valid, or if any
+// two consecutive positions are not connected by exactly one legal move.
+func GameFromPositions(positions []*Position) (*Game, error) {
+	if len(positions) == 0 {
+		return nil, errors.New("can't create game: no positions given")
+	}
+	for i, position := range positions {
+		if position == nil {
+			return nil, fmt.Errorf("can't create game: position %d is nil", i)
+		}
+	}
+	game := NewGame()
+	if GenerateFen(positions[0]) != DefaultFen {
+		if err := game.SetPosition(positions[0]); err != nil {
+			return nil, fmt.Errorf("can't create game: %w", err)
+		}
+	}
+	for i, position := range positions[1:] {
+		move, err := MoveBetween(game.position, position)
+		if err != nil {
+			return nil, fmt.Errorf("can't create game: position %d: %w", i+1, err)
+		}
+		if err := game.Move(move); err != nil {
+			return nil, fmt.Errorf("can't create game: position %d: %w", i+1, err)
+		}
+	}
+	return game, nil
+}
+
+// GameFromSAN creates a game starting from startFEN and plays each move in sans. An empty startFEN means the standard
+// starting position. If a move can't be parsed or isn't legal the returned error gives its index in sans.
+func GameFromSAN(startFEN string, sans []string) (*Game, error) {
+	game := NewGame()
+	if startFEN != "" {
+		position, err := ParseFen(startFEN)
+		if err != nil {
+			return nil, fmt.Errorf("can't create game: %w", err)
+		}
+		if err := game.SetPosition(position); err != nil {
+			return nil, fmt.Errorf("can't create game: %w", err)
+		}
+	}
+	for i, san := range sans {
+		if err := game.MoveSan(san); err != nil {
+			return nil, fmt.Errorf("can't create game: move %d, %s: %w", i, san, err)
+		}
+	}
+	return game, nil
+}
+
 // Move performs the given move. If move m is not legal g remains unchanged and an error is returned.
 // If the move is legal the result tag is set to * (NoResult). If the position ends in checkmate
 // or stalemate the result tag is updated accordingly.
 func (g *Game) Move(m Move) error {
-	legalMoves := GenerateLegalMoves(g.position)
-	if !slices.Contains(legalMoves, m) {
-		return fmt.Errorf("%s is not a legal move", m)
+	if err := g.checkMoveLegal(m); err != nil {
+		return err
+	}
+	g.applyMove(m)
+	return nil
+}
+
+// MoveWithAutoPromote performs m in the same way as [Game.Move], except that a pawn move to the last rank without a
+// promotion promotes to pt. If pt is [NoPieceType] the pawn promotes to a queen. This models the "auto-queen" setting
+// many chess interfaces have. The resulting move is checked for legality as normal.
+func (g *Game) MoveWithAutoPromote(m Move, pt PieceType) error {
+	if pt == NoPieceType {
+		pt = Queen
+	}
+	piece := g.position.PieceAt(m.FromSquare)
+	lastRank := Rank8
+	if piece.Color == Black {
+		lastRank = Rank1
 	}
+	if piece.Type == Pawn && m.ToSquare.Rank == lastRank && m.Promotion == NoPieceType {
+		m.Promotion = pt
+	}
+	return g.Move(m)
+}
+
+// MoveWithSAN performs the given move in the same way as [Game.Move], and returns the move in SAN as it was written
+// for the position before the move was made.
+func (g *Game) MoveWithSAN(m Move) (string, error) {
+	if err := g.checkMoveLegal(m); err != nil {
+		return "", err
+	}
+	san := m.SanString(g.position)
+	g.applyMove(m)
+	return san, nil
+}
+
+// applyMove performs m, which must already be known to be legal, and updates the result.
+func (g *Game) applyMove(m Move) {
 	g.position.Move(m)
 	g.moveHistory = append(g.moveHistory, m)
+	g.updateResult()
+	for _, observer := range g.observers {
+		observer(len(g.moveHistory), m, g.Position())
+	}
+}
+
+// updateResult sets the result to match a checkmate or stalemate in the current position. Otherwise the result is set
+// to * (NoResult), unless it was set by [Game.Resign] or [Game.AgreeDraw].
+func (g *Game) updateResult() {
+	if g.resultFinal && (IsCheckMate(g.position) || IsStaleMate(g.position)) {
+		g.SetTermination(NoTermination)
+	}
 	if IsCheckMate(g.position) {
 		if g.position.Turn == Black {
 			g.SetResult(WhiteWins)
@@ -107,21 +295,197 @@ func (g *Game) Move(m Move) error {
 		}
 	} else if IsStaleMate(g.position) {
 		g.SetResult(Draw)
-	} else {
+	} else if !g.resultFinal {
 		g.SetResult(NoResult)
 	}
+}
+
+// ReplaceFrom replaces the move history from ply onward with moves. The first ply moves are kept (so ply 0 replaces
+// every move) and then each of moves is played in turn. The result is recomputed from the new final position, and any
+// result and termination set by [Game.Resign] or [Game.AgreeDraw] are cleared. If ply is out of range or any of moves
+// is illegal, an error giving the index of the move is returned and g is unchanged.
+func (g *Game) ReplaceFrom(ply int, moves []Move) error {
+	if ply < 0 || ply > len(g.moveHistory) {
+		return fmt.Errorf("can't replace moves: ply %d out of range, game has %d plies", ply, len(g.moveHistory))
+	}
+	pos := g.StartPosition()
+	for _, m := range g.moveHistory[:ply] {
+		pos.Move(m)
+	}
+	checkPosition := *pos
+	check := &Game{position: &checkPosition, tags: map[string]string{}}
+	for i, m := range moves {
+		if err := check.Move(m); err != nil {
+			return fmt.Errorf("can't replace moves: move %d: %w", i, err)
+		}
+	}
+
+	*g.position = *pos
+	g.moveHistory = g.moveHistory[:ply]
+	if g.resultFinal {
+		g.SetTermination(NoTermination)
+	}
+	g.resultFinal = false
+	g.updateResult()
+	for _, m := range moves {
+		g.applyMove(m)
+	}
 	return nil
 }
 
+// OnMove registers fn to be called every time a move is played on g, through [Game.Move] or any of the functions that
+// use it. fn is called synchronously after the position and result are updated, with the ply of the move (starting
+// at 1), the move itself, and a copy of the new position. Observers are not kept by [Game.Copy].
+func (g *Game) OnMove(fn func(ply int, m Move, pos *Position)) {
+	g.observers = append(g.observers, fn)
+}
+
+func (g *Game) checkMoveLegal(m Move) error {
+	return g.MoveError(m)
+}
+
+// MoveError returns nil if m is legal in the current position, and otherwise an error describing why it isn't, such as
+// "no piece on E4", "not your turn", "path blocked", "no castling rights", or "would leave king in check". It is meant
+// for giving feedback to a user who tried an illegal move. [Game.Move] returns the same error.
+func (g *Game) MoveError(m Move) error {
+	if m.FromSquare == NoSquare || m.ToSquare == NoSquare || !isValidSquare(m.FromSquare) || !isValidSquare(m.ToSquare) {
+		return fmt.Errorf("illegal move %s: invalid move", m)
+	}
+	if err := checkPromotion(g.position, m); err != nil {
+		return err
+	}
+	if !isValidMove(m) {
+		return fmt.Errorf("illegal move %s: invalid move", m)
+	}
+	if slices.Contains(GenerateLegalMoves(g.position), m) {
+		return nil
+	}
+	if reason := illegalMoveReason(g.position, m); reason != "" {
+		return fmt.Errorf("illegal move %s: %s", m, reason)
+	}
+	return fmt.Errorf("%s is not a legal move", m)
+}
+
+// illegalMoveReason classifies why m, which is known not to be legal in p, can't be played. An empty string is
+// returned if no more specific reason than "not legal" can be given.
+func illegalMoveReason(p *Position, m Move) string {
+	piece := p.PieceAt(m.FromSquare)
+	if piece == NoPiece {
+		return fmt.Sprintf("no piece on %v", m.FromSquare)
+	}
+	if piece.Color != p.Turn {
+		return "not your turn"
+	}
+	if piece.Type == King && m.FromSquare.Rank == m.ToSquare.Rank && ChebyshevDistance(m.FromSquare, m.ToSquare) == 2 {
+		return illegalCastleReason(p, m)
+	}
+	if p.PieceAt(m.ToSquare).Color == p.Turn {
+		return "can't capture your own piece"
+	}
+	if slices.Contains(generatePieceMoves(p, m.FromSquare), m) {
+		return "would leave king in check"
+	}
+	if piece.Type == Rook || piece.Type == Bishop || piece.Type == Queen || piece.Type == Pawn {
+		for _, s := range SquaresBetween(m.FromSquare, m.ToSquare) {
+			if p.PieceAt(s) != NoPiece {
+				return "path blocked"
+			}
+		}
+	}
+	return fmt.Sprintf("%v can't move from %v to %v", piece.Type, m.FromSquare, m.ToSquare)
+}
+
+// illegalCastleReason classifies why the king move m, which moves two squares along a rank, isn't legal in p.
+func illegalCastleReason(p *Position, m Move) string {
+	if !isCastleMove(p, m) {
+		return "king can't move two squares"
+	}
+	kingSide := m.ToSquare.File == FileG
+	var hasRight bool
+	var rookSquare Square
+	switch {
+	case p.Turn == White && kingSide:
+		hasRight, rookSquare = p.WhiteKingSideCastle, H1
+	case p.Turn == White:
+		hasRight, rookSquare = p.WhiteQueenSideCastle, A1
+	case kingSide:
+		hasRight, rookSquare = p.BlackKingSideCastle, H8
+	default:
+		hasRight, rookSquare = p.BlackQueenSideCastle, A8
+	}
+	if !hasRight || p.PieceAt(rookSquare) != (Piece{p.Turn, Rook}) {
+		return "no castling rights"
+	}
+	for _, s := range SquaresBetween(m.FromSquare, rookSquare) {
+		if p.PieceAt(s) != NoPiece {
+			return "path blocked"
+		}
+	}
+	if IsCheck(p) {
+		return "can't castle out of check"
+	}
+	return "would leave king in check"
+}
+
+// PositionAfterMove returns the position that would result from playing m, without changing g. An error is returned if
+// m is not legal.
+func (g *Game) PositionAfterMove(m Move) (*Position, error) {
+	if err := g.checkMoveLegal(m); err != nil {
+		return nil, err
+	}
+	return g.position.AfterMove(m), nil
+}
+
 // MoveSan is a helper function that automatically performs an SAN formatted move. SAN format is specified here: http://www.saremba.de/chessgml/standards/pgn/pgn-complete.htm#c8.2.3
 func (g *Game) MoveSan(s string) error {
-	move, err := ParseSANMove(g.position, s)
+	move, err := g.ParseSAN(s)
 	if err != nil {
 		return err
 	}
 	return g.Move(move)
 }
 
+// ParseSAN parses the SAN move s against the game's current position without playing it. Like [ParseSANMove] the move
+// is not checked for legality. Use [Game.MoveSan] to parse and play a move.
+func (g *Game) ParseSAN(s string) (Move, error) {
+	return ParseSANMove(g.position, s)
+}
+
+// MergeGames combines two records of the same game, such as duplicates in a database. The games must start from the
+// same position, and one's move history must be the same as, or a prefix of, the other's. The merged game has the
+// longer move history and its result. Tags are taken from both games, preferring known values over unknown ones like
+// "?" or "????.??.??", and the longer game's value when both are known. An error is returned if the games start from
+// different positions or their moves diverge, since games in this package can only hold a single line of moves.
+func MergeGames(a *Game, b *Game) (*Game, error) {
+	if GenerateFen(a.StartPosition()) != GenerateFen(b.StartPosition()) {
+		return nil, errors.New("can't merge games: games start from different positions")
+	}
+	longer, shorter := a, b
+	if len(b.moveHistory) > len(a.moveHistory) {
+		longer, shorter = b, a
+	}
+	for i, m := range shorter.moveHistory {
+		if longer.moveHistory[i] != m {
+			return nil, fmt.Errorf("can't merge games: moves diverge at ply %d", i+1)
+		}
+	}
+	merged := longer.Copy()
+	for tag, value := range shorter.tags {
+		if tag == "Result" || tag == "SetUp" || tag == "FEN" || isUnknownTagValue(value) {
+			continue
+		}
+		if current, ok := merged.tags[tag]; !ok || isUnknownTagValue(current) {
+			merged.SetTag(tag, value)
+		}
+	}
+	return merged, nil
+}
+
+// isUnknownTagValue returns true for tag values that only mark a value as unknown, such as "", "?", or "????.??.??".
+func isUnknownTagValue(value string) bool {
+	return strings.Trim(value, "?.") == ""
+}
+
 // Returns a copy of current game.
 func (g *Game) Copy() *Game {
 	positionCopy := *g.position
@@ -129,6 +493,8 @@ func (g *Game) Copy() *Game {
 		position:    &positionCopy,
 		moveHistory: slices.Clone(g.moveHistory),
 		tags:        maps.Clone(g.tags),
+		resultFinal: g.resultFinal,
+		termination: g.termination,
 	}
 	return gameCopy
 }
@@ -144,6 +510,27 @@ func (g *Game) Position() *Position {
 	return &pos
 }
 
+// Moves returns a copy of the game's move history, from the start position to the current position. Games only store
+// the main line, so there are no variations or comments to copy.
+func (g *Game) Moves() []Move {
+	return slices.Clone(g.moveHistory)
+}
+
+// LastMove returns the most recent move in the game's move history. ok is false if no moves have been played.
+func (g *Game) LastMove() (m Move, ok bool) {
+	if len(g.moveHistory) == 0 {
+		return Move{}, false
+	}
+	return g.moveHistory[len(g.moveHistory)-1], true
+}
+
+// Ply returns the number of half moves played in the game's move history. It does not count moves made before the
+// position was set with [Game.SetPosition].
+func (g *Game) Ply() int {
+	return len(g.moveHistory)
+}
+
+// Turn returns the side to move without copying the game's position.
 func (g *Game) Turn() Color {
 	return g.position.Turn
 }
@@ -164,10 +551,12 @@ func (g *Game) BlackQueenSideCastle() bool {
 	return g.position.BlackQueenSideCastle
 }
 
+// HalfMove returns the half move clock used for the fifty move rule.
 func (g *Game) HalfMove() uint16 {
 	return g.position.HalfMove
 }
 
+// FullMove returns the full move number of the game's current position.
 func (g *Game) FullMove() uint16 {
 	return g.position.FullMove
 }
@@ -176,6 +565,11 @@ func (g *Game) EnPassant() Square {
 	return g.position.EnPassant
 }
 
+// IsCheck returns true if the side to move is currently in check.
+func (g *Game) IsCheck() bool {
+	return IsCheck(g.position)
+}
+
 // IsCheckMate returns true is the side to move is in check and has no legal moves.
 func (g *Game) IsCheckMate() bool {
 	return IsCheckMate(g.position)
@@ -186,20 +580,101 @@ func (g *Game) IsStaleMate() bool {
 	return IsStaleMate(g.position)
 }
 
+// MatedColor returns the color that is checkmated in the current position. ok is false if the position isn't
+// checkmate.
+func (g *Game) MatedColor() (c Color, ok bool) {
+	if !g.IsCheckMate() {
+		return NoColor, false
+	}
+	return g.position.Turn, true
+}
+
+// Termination returns the reason the game ended. This is the termination recorded by [Game.SetTermination] or read from
+// the Termination tag, such as when reading a pgn. If none has been recorded, [Checkmate] or [Stalemate] is returned
+// when the current position is one, and [NoTermination] otherwise.
+func (g *Game) Termination() Termination {
+	if g.termination != NoTermination {
+		return g.termination
+	}
+	if g.IsCheckMate() {
+		return Checkmate
+	}
+	if g.IsStaleMate() {
+		return Stalemate
+	}
+	return NoTermination
+}
+
+// SetTermination records t as the reason the game ended and sets the Termination tag to match, so that
+// [Game.Termination] returns t. The values from the pgn standard are used where one exists, such as "time forfeit" for
+// [Timeout] and "normal" for [Normal]; other terminations use their String value. [NoTermination] removes the tag. The
+// result is not changed.
+func (g *Game) SetTermination(t Termination) {
+	tag, ok := terminationTag(t)
+	if !ok {
+		g.termination = NoTermination
+		delete(g.tags, "Termination")
+		return
+	}
+	g.termination = t
+	g.tags["Termination"] = tag
+}
+
 // GetResult gets the current result tag for the game. This result should be valid, but there are no calculations being
 // performed in this function.
 func (g *Game) GetResult() Result {
 	return parseResult(g.tags["Result"])
 }
 
+// SetResult sets the result tag for the game. The result may be changed by later calls to [Game.Move]. Setting the
+// result of a finished game back to * (NoResult) also removes its termination, see [Game.SetTermination].
 func (g *Game) SetResult(r Result) {
+	if r == NoResult && g.GetResult() != NoResult {
+		g.SetTermination(NoTermination)
+	}
 	g.tags["Result"] = r.String()
+	g.resultFinal = false
+}
+
+// Resign ends the game with a win for the opponent of loser, and records the termination as [Resignation] with
+// [Game.SetTermination]. Unlike [Game.SetResult], the result is kept if more moves are played, unless one of those
+// moves ends in checkmate or stalemate, in which case the result is updated to match the board and the recorded
+// termination is removed, so [Game.Termination] reports the checkmate or stalemate.
+func (g *Game) Resign(loser Color) {
+	if loser == White {
+		g.SetResult(BlackWins)
+	} else if loser == Black {
+		g.SetResult(WhiteWins)
+	} else {
+		return
+	}
+	g.SetTermination(Resignation)
+	g.resultFinal = true
+}
+
+// AgreeDraw ends the game in a draw by agreement, and records the termination as [Agreement] with
+// [Game.SetTermination]. The result is kept in the same way as with [Game.Resign].
+func (g *Game) AgreeDraw() {
+	g.SetResult(Draw)
+	g.SetTermination(Agreement)
+	g.resultFinal = true
 }
 
 func (g *Game) LegalMoves() []Move {
 	return GenerateLegalMoves(g.position)
 }
 
+// LegalMovesSAN returns every legal move in the current position in SAN, in the same order as [Game.LegalMoves]. Moves
+// are disambiguated against each other, so every string is distinct and can be given to [Game.MoveSan].
+func (g *Game) LegalMovesSAN() []string {
+	legalMoves := g.LegalMoves()
+	sans := make([]string, 0, len(legalMoves))
+	for _, m := range legalMoves {
+		sans = append(sans, m.SanString(g.position))
+	}
+	return sans
+}
+
 func (g *Game) GetTag(t string) (string, error) {
 	s, ok := g.tags[t]
 	if !ok {
@@ -208,11 +683,93 @@ func (g *Game) GetTag(t string) (string, error) {
 	return s, nil
 }
 
+// IntTag returns the value of tag t parsed as an integer. ok is false if the tag is missing or is not an integer.
+func (g *Game) IntTag(t string) (value int, ok bool) {
+	s, exists := g.tags[t]
+	if !exists {
+		return 0, false
+	}
+	value, err := strconv.Atoi(strings.TrimSpace(s))
+	if err != nil {
+		return 0, false
+	}
+	return value, true
+}
+
+// WhiteElo returns the value of the WhiteElo tag. ok is false if the tag is missing or is not an integer.
+func (g *Game) WhiteElo() (elo int, ok bool) {
+	return g.IntTag("WhiteElo")
+}
+
+// BlackElo returns the value of the BlackElo tag. ok is false if the tag is missing or is not an integer.
+func (g *Game) BlackElo() (elo int, ok bool) {
+	return g.IntTag("BlackElo")
+}
+
+// ECO returns the value of the ECO opening code tag. ok is false if the tag is missing, empty, or unknown ("?").
+func (g *Game) ECO() (eco string, ok bool) {
+	eco, exists := g.tags["ECO"]
+	if !exists || eco == "" || eco == "?" {
+		return "", false
+	}
+	return eco, true
+}
+
+// ParsedDate reads the Date tag. The pgn form "YYYY.MM.DD" is accepted along with "-" or "/" separators, as in ISO
+// dates, and partial dates such as "2024" or "2024-05". Unknown parts, written as question marks or left out, are
+// returned as 0. ok is false if there is no Date tag or it can't be read.
+func (g *Game) ParsedDate() (year int, month int, day int, ok bool) {
+	date, hasDate := g.tags["Date"]
+	if !hasDate {
+		return 0, 0, 0, false
+	}
+	parts := strings.FieldsFunc(date, func(r rune) bool { return r == '.' || r == '-' || r == '/' })
+	if len(parts) == 0 || len(parts) > 3 {
+		return 0, 0, 0, false
+	}
+	values := [3]int{}
+	limits := [3]int{9999, 12, 31}
+	for i, part := range parts {
+		if strings.Trim(part, "?") == "" {
+			continue
+		}
+		value, err := strconv.Atoi(part)
+		if err != nil || value < 1 || value > limits[i] {
+			return 0, 0, 0, false
+		}
+		values[i] = value
+	}
+	return values[0], values[1], values[2], true
+}
+
+// NormalizeDate rewrites the Date tag in the pgn form "YYYY.MM.DD", with question marks for unknown parts. A Date tag
+// that can't be read by [Game.ParsedDate] is left unchanged.
+func (g *Game) NormalizeDate() {
+	year, month, day, ok := g.ParsedDate()
+	if !ok {
+		return
+	}
+	date := "????.??.??"
+	if year != 0 {
+		date = fmt.Sprintf("%04d", year) + date[4:]
+	}
+	if month != 0 {
+		date = date[:5] + fmt.Sprintf("%02d", month) + date[7:]
+	}
+	if day != 0 {
+		date = date[:8] + fmt.Sprintf("%02d", day)
+	}
+	g.tags["Date"] = date
+}
+
 // SetTag sets any tag for the game so that it will show up in the pgn file. The Result, SetUp, and FEN tags cannot be set with this function. Please use the [Game.SetResult] function to set the result, and the [Game.SetPosition] function to set the other two tags.
 func (g *Game) SetTag(tag string, value string) {
 	if tag == "Result" || tag == "SetUp" || tag == "FEN" {
 		return
 	}
+	if tag == "Termination" {
+		g.termination = parseTermination(value)
+	}
 	g.tags[tag] = value
 }
 
@@ -227,6 +784,9 @@ func (g *Game) RemoveTag(tag string) {
 	if slices.Contains(requiredTags, tag) {
 		return
 	}
+	if tag == "Termination" {
+		g.termination = NoTermination
+	}
 	delete(g.tags, tag)
 }
 
@@ -259,25 +819,85 @@ func (g *Game) SetPosition(p *Position) error {
 	return nil
 }
 
-// HasThreeFoldRepetition returns true if the game has been in the exact same position (including castling rights)
-// At least three times at any point during the entire game.
+// EnPassantSquare returns the en passant square of the game's current position, or [NoSquare] if there is none. The
+// square is returned whether or not an en passant capture is actually legal.
+func (g *Game) EnPassantSquare() Square {
+	return g.position.EnPassant
+}
+
+// SetEnPassantSquare sets the en passant square of the game's current position to s, using [Game.SetPosition]. This is
+// meant for setting up positions, so like SetPosition it clears the move history and updates the FEN tag. s must be
+// [NoSquare], or an empty square on rank 6 (white to move) or rank 3 (black to move) just behind a pawn of the side
+// that isn't to move. Otherwise an error is returned and g is unchanged.
+func (g *Game) SetEnPassantSquare(s Square) error {
+	pos := g.Position()
+	pos.EnPassant = s
+	if err := g.SetPosition(pos); err != nil {
+		return fmt.Errorf("invalid en passant square %v: %w", s, err)
+	}
+	return nil
+}
+
+// HasThreeFoldRepetition returns true if the game has been in the exact same position (including castling rights, and
+// the en passant square if an en passant capture is legal) at least three times at any point during the entire game.
+// Only positions between irreversible moves (pawn moves, captures, and moves that lose castling rights) are compared,
+// since a position can't repeat across one.
 func (g *Game) HasThreeFoldRepetition() bool {
-	// TODO Use a map instead for more efficiency
 	allPositions := generateAllGamePositions(g)
-	for index, pos1 := range allPositions[:len(allPositions)-1] {
-		numEquivalentPositions := 1
-		for _, pos2 := range allPositions[index+1:] {
-			if positionsEqualNoMoveCounter(&pos1, &pos2) {
-				numEquivalentPositions++
-				if numEquivalentPositions >= 3 {
-					return true
-				}
-			}
+	occurrences := map[Position]int{}
+	for index := range allPositions {
+		if index > 0 && isIrreversiblePosition(&allPositions[index-1], &allPositions[index]) {
+			clear(occurrences)
+		}
+		key := repetitionKey(&allPositions[index])
+		occurrences[key]++
+		if occurrences[key] >= 3 {
+			return true
 		}
 	}
 	return false
 }
 
+// ThreeFoldPositions returns the plies at which the game's current position has occurred, ordered from earliest to
+// latest and including the current ply. Ply 0 is the game's start position. The bool is true if the position has
+// occurred at least three times, meaning a draw by three fold repetition can be claimed on the current position.
+// Earlier plies are only searched back to the most recent irreversible move.
+func (g *Game) ThreeFoldPositions() ([]int, bool) {
+	allPositions := generateAllGamePositions(g)
+	current := allPositions[len(allPositions)-1]
+	plies := []int{}
+	for ply := len(allPositions) - 1; ply >= 0; ply-- {
+		if positionsEqualNoMoveCounter(&allPositions[ply], &current) {
+			plies = append(plies, ply)
+		}
+		if ply > 0 && isIrreversiblePosition(&allPositions[ply-1], &allPositions[ply]) {
+			break
+		}
+	}
+	slices.Reverse(plies)
+	return plies, len(plies) >= 3
+}
+
+// isIrreversiblePosition returns true if the move from prev to pos can't be undone, because it reset the half move
+// clock or lost a castling right. No position before pos can then be equal to pos or any position after it.
+func isIrreversiblePosition(prev *Position, pos *Position) bool {
+	return pos.HalfMove == 0 ||
+		prev.WhiteKingSideCastle != pos.WhiteKingSideCastle ||
+		prev.WhiteQueenSideCastle != pos.WhiteQueenSideCastle ||
+		prev.BlackKingSideCastle != pos.BlackKingSideCastle ||
+		prev.BlackQueenSideCastle != pos.BlackQueenSideCastle
+}
+
+// repetitionKey returns a copy of p that is equal to the key of another position exactly when
+// positionsEqualNoMoveCounter reports the two positions as equal.
+func repetitionKey(p *Position) Position {
+	key := *p
+	key.EnPassant = legalEnPassantSquare(p)
+	key.HalfMove = 0
+	key.FullMove = 0
+	return key
+}
+
 // PrintPosition prints the current position from the point of view for the current player to move.
 func (g *Game) PrintPosition() {
 	if g.Turn() == Black {
@@ -287,11 +907,66 @@ func (g *Game) PrintPosition() {
 	}
 }
 
-func generateAllGamePositions(g *Game) []Position {
+// StartPosition returns a copy of the position the game's move history starts from. This is the position given by the FEN
+// tag if it is present, and the standard starting position otherwise.
+func (g *Game) StartPosition() *Position {
 	pos, _ := ParseFen(DefaultFen)
 	if fen, err := g.GetTag("FEN"); err == nil {
 		pos, _ = ParseFen(fen)
 	}
+	return pos
+}
+
+// Validate checks that g is internally consistent. The move history is replayed from the FEN tag (or the standard
+// starting position) to confirm every move is legal and that the result is the game's current position. The result
+// must also agree with the final position if it is checkmate or stalemate. A nil error means g is consistent.
+func (g *Game) Validate() error {
+	start := NewGame()
+	if fen, err := g.GetTag("FEN"); err == nil {
+		pos, err := ParseFen(fen)
+		if err != nil {
+			return fmt.Errorf("invalid game: invalid FEN tag: %w", err)
+		}
+		if err := start.SetPosition(pos); err != nil {
+			return fmt.Errorf("invalid game: invalid FEN tag: %w", err)
+		}
+	}
+	for i, m := range g.moveHistory {
+		if err := start.Move(m); err != nil {
+			return fmt.Errorf("invalid game: ply %d: %w", i+1, err)
+		}
+	}
+	if *start.position != *g.position {
+		return fmt.Errorf("invalid game: position %s does not match the move history, which gives %s",
+			GenerateFen(g.position), GenerateFen(start.position))
+	}
+	if g.IsCheckMate() || g.IsStaleMate() {
+		if result := start.GetResult(); g.GetResult() != result {
+			return fmt.Errorf("invalid game: result is %v, but the final position gives %v", g.GetResult(), result)
+		}
+	}
+	return nil
+}
+
+// IsFromStartingPosition returns true if the game's move history starts from the standard starting position, meaning
+// the FEN tag is either absent or equal to [DefaultFen].
+func (g *Game) IsFromStartingPosition() bool {
+	return GenerateFen(g.StartPosition()) == DefaultFen
+}
+
+// FENHistory returns the FEN of every position in the game, starting with [Game.StartPosition] and followed by the
+// position after each move in the move history.
+func (g *Game) FENHistory() []string {
+	allPositions := generateAllGamePositions(g)
+	fens := make([]string, 0, len(allPositions))
+	for _, pos := range allPositions {
+		fens = append(fens, GenerateFen(&pos))
+	}
+	return fens
+}
+
+func generateAllGamePositions(g *Game) []Position {
+	pos := g.StartPosition()
 	allPositions := make([]Position, 0, g.FullMove()*2+1)
 	allPositions = append(allPositions, *pos)
 	for _, move := range g.moveHistory {
@@ -308,7 +983,13 @@ func positionsEqualNoMoveCounter(pos1 *Position, pos2 *Position) bool {
 		pos1.WhiteQueenSideCastle == pos2.WhiteQueenSideCastle &&
 		pos1.BlackKingSideCastle == pos2.BlackKingSideCastle &&
 		pos1.BlackQueenSideCastle == pos2.BlackQueenSideCastle &&
-		pos1.EnPassant == pos2.EnPassant
+		legalEnPassantSquare(pos1) == legalEnPassantSquare(pos2)
+}
+
+// CanClaimDrawFiftyMove returns true if the half move counter is >= 100, meaning no piece has been taken, nor pawn
+// moved for fifty moves. Unlike [Game.CanClaimDraw] it does not check for checkmate.
+func (g *Game) CanClaimDrawFiftyMove() bool {
+	return g.position.HalfMove >= 100
 }
 
 // CanClaimDraw returns true if one of the following conditions is true and the game is not in checkmate
@@ -316,13 +997,25 @@ func positionsEqualNoMoveCounter(pos1 *Position, pos2 *Position) bool {
 //   - The game contains a three fold repetition (the exact same position has occurred three times in the game)
 //   - The game is in stalemate
 func (g *Game) CanClaimDraw() bool {
-	return (g.position.HalfMove >= 100 ||
+	return (g.CanClaimDrawFiftyMove() ||
 		g.HasThreeFoldRepetition() ||
 		g.IsStaleMate()) && !g.IsCheckMate()
 }
 
 // WritePgn writes a pgn representation of g to w.
 func WritePgn(g *Game, w io.Writer) error {
+	return WritePgnOpts(g, w, PgnWriteOptions{})
+}
+
+// PgnWriteOptions changes how [WritePgnOpts] writes a pgn. The zero value matches [WritePgn].
+type PgnWriteOptions struct {
+	// ZeroCastling writes castling with the digit zero, as "0-0" and "0-0-0", instead of the letter O used by the pgn
+	// standard. Some databases expect this form.
+	ZeroCastling bool
+}
+
+// WritePgnOpts writes a pgn in the same way as [WritePgn], with the behavior changes given by opts.
+func WritePgnOpts(g *Game, w io.Writer, opts PgnWriteOptions) error {
 	sevenTags := []string{"Event", "Site", "Date", "Round", "White", "Black", "Result"}
 	for _, tag := range sevenTags {
 		_, err := fmt.Fprintf(w, "[%s \"%s\"]\n", tag, g.tags[tag])
@@ -342,7 +1035,11 @@ func WritePgn(g *Game, w io.Writer) error {
 	if err != nil {
 		return fmt.Errorf("unable to write pgn: %w", err)
 	}
+	return writePgnMovetext(g, w, opts)
+}
 
+// writePgnMovetext writes the moves of g in SAN with move numbers, followed by the result.
+func writePgnMovetext(g *Game, w io.Writer, opts PgnWriteOptions) error {
 	newGame := NewGame()
 	if fen, keyExists := g.tags["FEN"]; keyExists {
 		new_position, err := ParseFen(fen)
@@ -351,38 +1048,189 @@ func WritePgn(g *Game, w io.Writer) error {
 		}
 		newGame.SetPosition(new_position)
 	}
-	counter := 1
-	for _, move := range g.moveHistory {
-		sanMoveString := move.SanString(newGame.position)
-		if newGame.Turn() == White {
-			_, err := fmt.Fprintf(w, "%d. %s ", counter, sanMoveString)
-			if err != nil {
-				return fmt.Errorf("unable to write pgn: %w", err)
-			}
-			counter++
+	startPly := positionPly(newGame.position)
+	buf := []byte{}
+	for ply, move := range g.moveHistory {
+		buf = buf[:0]
+		fullMove, isWhite := PlyToMoveNumber(startPly, ply)
+		if isWhite {
+			buf = strconv.AppendInt(buf, int64(fullMove), 10)
+			buf = append(buf, ". "...)
+		} else if ply == 0 {
+			buf = strconv.AppendInt(buf, int64(fullMove), 10)
+			buf = append(buf, "... "...)
 		}
-		if newGame.Turn() == Black {
-			_, err := fmt.Fprintf(w, "%s ", sanMoveString)
-			if err != nil {
-				return fmt.Errorf("unable to write pgn: %w", err)
+		sanStart := len(buf)
+		var err error
+		buf, err = move.AppendSan(buf, newGame.position)
+		if err != nil {
+			return fmt.Errorf("unable to write pgn: %w", err)
+		}
+		if opts.ZeroCastling && isCastleMove(newGame.position, move) {
+			for i := sanStart; i < len(buf); i++ {
+				if buf[i] == 'O' {
+					buf[i] = '0'
+				}
 			}
 		}
-		err := newGame.Move(move)
+		buf = append(buf, ' ')
+		if _, err := w.Write(buf); err != nil {
+			return fmt.Errorf("unable to write pgn: %w", err)
+		}
+		err = newGame.Move(move)
 		if err != nil {
 			return fmt.Errorf("unable to write pgn: %w", err)
 		}
 	}
-	_, err = fmt.Fprintf(w, "%s", g.GetResult().String())
+	_, err := fmt.Fprintf(w, "%s", g.GetResult().String())
 	if err != nil {
 		return fmt.Errorf("unable to write pgn: %w", err)
 	}
 	return nil
 }
 
+// PlyToMoveNumber returns the full move number of the move at index ply of a game's move history, and whether it is a
+// move by white. startPly is the number of half moves that would have been played from the standard starting position
+// to reach the game's start position: 0 if white is to move on move 1, 1 if black is to move on move 1, 2 if white is
+// to move on move 2, and so on. This matches the move numbers written by [WritePgn], such as "16... Qd3" for a game
+// that starts with black to move on move 16.
+func PlyToMoveNumber(startPly int, ply int) (fullMove int, isWhite bool) {
+	absolutePly := startPly + ply
+	return absolutePly/2 + 1, absolutePly%2 == 0
+}
+
+// positionPly returns the startPly of p for [PlyToMoveNumber], using its full move counter and side to move.
+func positionPly(p *Position) int {
+	ply := 2 * (max(int(p.FullMove), 1) - 1)
+	if p.Turn == Black {
+		ply++
+	}
+	return ply
+}
+
+// WriteReducedTo writes g to w in the reduced export format described by the pgn standard: only the seven tag roster
+// is written, followed by the SetUp and FEN tags if the game has a FEN tag, then the moves. It returns the number of
+// bytes written.
+func (g *Game) WriteReducedTo(w io.Writer) (int64, error) {
+	cw := &countingWriter{w: w}
+	tags := []string{"Event", "Site", "Date", "Round", "White", "Black", "Result"}
+	if _, hasFEN := g.tags["FEN"]; hasFEN {
+		tags = append(tags, "SetUp", "FEN")
+	}
+	for _, tag := range tags {
+		val := g.tags[tag]
+		if tag == "SetUp" {
+			val = "1"
+		}
+		_, err := fmt.Fprintf(cw, "[%s \"%s\"]\n", tag, val)
+		if err != nil {
+			return cw.n, fmt.Errorf("unable to write pgn: %w", err)
+		}
+	}
+	_, err := fmt.Fprint(cw, "\n")
+	if err != nil {
+		return cw.n, fmt.Errorf("unable to write pgn: %w", err)
+	}
+	err = writePgnMovetext(g, cw, PgnWriteOptions{})
+	return cw.n, err
+}
+
+// WritePgnReduced writes each game in games to w using [Game.WriteReducedTo], separated by blank lines. Games are
+// written one at a time, so large collections can be exported without building the whole output in memory.
+func WritePgnReduced(w io.Writer, games []*Game) error {
+	for i, g := range games {
+		if i > 0 {
+			if _, err := fmt.Fprint(w, "\n\n"); err != nil {
+				return fmt.Errorf("unable to write pgn: %w", err)
+			}
+		}
+		if _, err := g.WriteReducedTo(w); err != nil {
+			return fmt.Errorf("game %d: %w", i+1, err)
+		}
+	}
+	_, err := fmt.Fprint(w, "\n")
+	if err != nil {
+		return fmt.Errorf("unable to write pgn: %w", err)
+	}
+	return nil
+}
+
+// ConvertPgn reads every game in r and writes it to w, with [WritePgn] or, if reduced is true, in the reduced export
+// format of [Game.WriteReducedTo]. Games are read and written one at a time, so large files can be converted without
+// holding them in memory. A game that can't be read is skipped and the conversion continues; the errors for all skipped
+// games are joined into the returned error. An error writing to w stops the conversion immediately.
+func ConvertPgn(r io.Reader, w io.Writer, reduced bool) error {
+	reader := bufio.NewReader(r)
+	gameErrors := []error{}
+	written := 0
+	for gameNumber := 1; ; gameNumber++ {
+		gameText, err := readPgnGameText(reader)
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return fmt.Errorf("convert pgn failed: %w", err)
+		}
+		game, err := ReadPgn(strings.NewReader(gameText))
+		if err != nil {
+			gameErrors = append(gameErrors, fmt.Errorf("game %d: %w", gameNumber, err))
+			continue
+		}
+		if written > 0 {
+			if _, err := fmt.Fprint(w, "\n\n"); err != nil {
+				return fmt.Errorf("unable to write pgn: %w", err)
+			}
+		}
+		if reduced {
+			_, err = game.WriteReducedTo(w)
+		} else {
+			err = WritePgn(game, w)
+		}
+		if err != nil {
+			return fmt.Errorf("game %d: %w", gameNumber, err)
+		}
+		written++
+	}
+	if written > 0 {
+		if _, err := fmt.Fprint(w, "\n"); err != nil {
+			return fmt.Errorf("unable to write pgn: %w", err)
+		}
+	}
+	return errors.Join(gameErrors...)
+}
+
+// countingWriter counts the bytes written to w.
+type countingWriter struct {
+	w io.Writer
+	n int64
+}
+
+func (cw *countingWriter) Write(p []byte) (int, error) {
+	n, err := cw.w.Write(p)
+	cw.n += int64(n)
+	return n, err
+}
+
 // ReadPgn attempts to create a [Game] from r. Parsing should be improved in the future, but for now only well formatted
 // pgns containing a single game are accepted. Refer to http://www.saremba.de/chessgml/standards/pgn/pgn-complete.htm for
 // specific details of how a pgn should be formatted.
 func ReadPgn(r io.Reader) (*Game, error) {
+	return ReadPgnOpts(r, PgnReadOptions{})
+}
+
+// PgnReadOptions changes how [ReadPgnOpts] reads a pgn. The zero value matches [ReadPgn].
+type PgnReadOptions struct {
+	// Lenient makes the reader recover from common mistakes instead of returning an error. Movetext after the first
+	// result token is ignored, rather than being an error, and tag lines that appear within the movetext are skipped.
+	Lenient bool
+	// VerifyResults, if set, is called with a warning for each way the game's stated result disagrees with its final
+	// position: a decisive result without checkmate, a draw when no draw can be claimed, or a result that contradicts a
+	// checkmate or stalemate on the board. Warnings don't stop the game from being read.
+	VerifyResults func(warning error)
+}
+
+// ReadPgnOpts reads a pgn in the same way as [ReadPgn], with the behavior changes given by opts.
+func ReadPgnOpts(r io.Reader, opts PgnReadOptions) (*Game, error) {
 	pgn_bytes, err := io.ReadAll(r)
 	if err != nil {
 		return nil, fmt.Errorf("read pgn failed: %w", err)
@@ -392,16 +1240,20 @@ func ReadPgn(r io.Reader) (*Game, error) {
 
 	game := NewGame()
 	var result Result
+	var termination string
+	var hasTermination bool
 	tagsComplete := false
+	movetext := strings.Builder{}
 	for _, line := range pgn_lines {
 		if tagsComplete {
-			err := parsePgnMoves(game, line)
-			if err != nil {
-				return nil, fmt.Errorf("read pgn failed: %w", err)
+			if opts.Lenient && isPgnTagLine(line) {
+				continue
 			}
+			movetext.WriteString(line + "\n")
 		} else if line == "" {
 			tagsComplete = true
 			result = game.GetResult()
+			termination, hasTermination = game.tags["Termination"]
 		} else {
 			err := parsePgnTag(game, line)
 			if err != nil {
@@ -410,35 +1262,327 @@ func ReadPgn(r io.Reader) (*Game, error) {
 		}
 	}
 
+	err = parsePgnMoves(game, movetext.String(), opts)
+	if err != nil {
+		return nil, fmt.Errorf("read pgn failed: %w", err)
+	}
+
 	game.SetResult(result)
+	if hasTermination {
+		game.SetTag("Termination", termination)
+	}
+
+	if opts.VerifyResults != nil {
+		for _, warning := range resultDiscrepancies(game) {
+			opts.VerifyResults(warning)
+		}
+	}
 
 	return game, nil
 }
 
-func parsePgnMoves(g *Game, moves string) error {
-	splitMoves := strings.Split(moves, " ")
+// isPgnTagLine returns true if line looks like a tag pair, such as [Event "?"].
+func isPgnTagLine(line string) bool {
+	line = strings.TrimSpace(line)
+	return strings.HasPrefix(line, "[") && strings.HasSuffix(line, "]")
+}
+
+// resultDiscrepancies returns a warning for each way g's result disagrees with its final position.
+func resultDiscrepancies(g *Game) []error {
+	warnings := []error{}
+	result := g.GetResult()
+	switch {
+	case g.IsCheckMate():
+		expected := WhiteWins
+		if g.Turn() == White {
+			expected = BlackWins
+		}
+		if result != expected {
+			warnings = append(warnings, fmt.Errorf("result %v but %v is checkmated", result, g.Turn()))
+		}
+	case g.IsStaleMate():
+		if result != Draw {
+			warnings = append(warnings, fmt.Errorf("result %v but the game ended in stalemate", result))
+		}
+	case result == WhiteWins || result == BlackWins:
+		warnings = append(warnings, fmt.Errorf("result %v but the game did not end in checkmate", result))
+	case result == Draw && !g.CanClaimDraw():
+		warnings = append(warnings, fmt.Errorf("result %v but no draw can be claimed", result))
+	}
+	return warnings
+}
+
+// IndexPgn returns the byte offset of the start of each game in a pgn file containing multiple games. The offsets can
+// be given to [ReadPgnAt] to read individual games without parsing the whole file.
+func IndexPgn(r io.ReadSeeker) ([]int64, error) {
+	if _, err := r.Seek(0, io.SeekStart); err != nil {
+		return nil, fmt.Errorf("index pgn failed: %w", err)
+	}
+	reader := bufio.NewReader(r)
+	offsets := []int64{}
+	var offset int64 = 0
+	gameEnded := true
+	for {
+		line, err := reader.ReadString('\n')
+		if strings.HasPrefix(line, "[") && gameEnded {
+			offsets = append(offsets, offset)
+			gameEnded = false
+		} else if !strings.HasPrefix(line, "[") && hasPgnResult(line) {
+			gameEnded = true
+		}
+		offset += int64(len(line))
+		if err == io.EOF {
+			return offsets, nil
+		}
+		if err != nil {
+			return nil, fmt.Errorf("index pgn failed: %w", err)
+		}
+	}
+}
+
+// ReadPgnAt reads the game starting at offset in r. Offsets should be obtained from [IndexPgn].
+func ReadPgnAt(r io.ReadSeeker, offset int64) (*Game, error) {
+	if _, err := r.Seek(offset, io.SeekStart); err != nil {
+		return nil, fmt.Errorf("read pgn failed: %w", err)
+	}
+	gameText, err := readPgnGameText(bufio.NewReader(r))
+	if err != nil {
+		return nil, fmt.Errorf("read pgn failed: %w", err)
+	}
+	return ReadPgn(strings.NewReader(gameText))
+}
+
+// readPgnGameText reads the text of a single game from r, stopping just before the tags of the next game. A game only
+// ends once its movetext has a result, so a stray tag line inside the movetext does not start a new game. Surrounding
+// blank lines are trimmed so the result can be passed directly to [ReadPgn]. io.EOF is returned if r has no more games.
+func readPgnGameText(r *bufio.Reader) (string, error) {
+	gameText := strings.Builder{}
+	gameEnded := false
+	for {
+		if gameEnded {
+			next, err := r.Peek(1)
+			if err == nil && next[0] == '[' {
+				break
+			}
+		}
+		line, err := r.ReadString('\n')
+		if !strings.HasPrefix(line, "[") && hasPgnResult(line) {
+			gameEnded = true
+		}
+		gameText.WriteString(line)
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return "", err
+		}
+	}
+	trimmed := strings.Trim(strings.ReplaceAll(gameText.String(), "\r", ""), "\n")
+	if trimmed == "" {
+		return "", io.EOF
+	}
+	return trimmed, nil
+}
+
+// hasPgnResult returns true if the movetext line contains a game termination marker (1-0, 0-1, 1/2-1/2 or *), either
+// as its own token or written directly against a move.
+func hasPgnResult(line string) bool {
+	for _, token := range strings.Fields(removePgnComments(line)) {
+		for _, result := range []string{"1-0", "0-1", "1/2-1/2", "*"} {
+			if strings.HasSuffix(token, result) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// parsePgnMoves plays each move in the movetext moves on g. Comments, move numbers, en passant markers ("e.p."), and
+// numeric annotation glyphs (of any value, such as $1 or $300) are ignored. A move number or result written directly
+// against a move without a space (e.g. "1.e4" or "Qf2#1-0") is separated from the move. Anything after the result is
+// an error, unless opts.Lenient is set in which case it is ignored.
+func parsePgnMoves(g *Game, moves string, opts PgnReadOptions) error {
 	possibleResults := []string{"1-0", "0-1", "1/2-1/2", "*"}
 
-	for _, move := range splitMoves {
-		if strings.Contains(move, ".") || slices.Contains(possibleResults, move) {
+	tokens := strings.Fields(removePgnComments(moves))
+	for i, move := range tokens {
+		move = trimPgnMoveNumber(move)
+		if move == "" || move == "e.p." || isNumericAnnotationGlyph(move) {
 			continue
 		}
-		err := g.MoveSan(move)
-		if err != nil {
-			return err
+		hasResult := false
+		for _, result := range possibleResults {
+			if strings.HasSuffix(move, result) {
+				move = strings.TrimSuffix(move, result)
+				hasResult = true
+				break
+			}
+		}
+		if move != "" {
+			err := g.MoveSan(move)
+			if err != nil {
+				return err
+			}
+		}
+		if hasResult {
+			if opts.Lenient || i == len(tokens)-1 {
+				return nil
+			}
+			return fmt.Errorf("movetext continues after result: %s", strings.Join(tokens[i+1:], " "))
 		}
 	}
 	return nil
 }
 
+// trimPgnMoveNumber removes a move number such as "12." or "12..." from the start of token. Tokens that don't start
+// with a move number, such as "exd6e.p.", are returned unchanged.
+func trimPgnMoveNumber(token string) string {
+	digits := 0
+	for digits < len(token) && token[digits] >= '0' && token[digits] <= '9' {
+		digits++
+	}
+	if digits == 0 || digits == len(token) || token[digits] != '.' {
+		return token
+	}
+	return strings.TrimLeft(token[digits:], ".")
+}
+
+func isNumericAnnotationGlyph(s string) bool {
+	if len(s) < 2 || s[0] != '$' {
+		return false
+	}
+	for _, char := range s[1:] {
+		if char < '0' || char > '9' {
+			return false
+		}
+	}
+	return true
+}
+
+// removePgnComments removes brace comments and rest of line comments (starting with ';') from movetext.
+func removePgnComments(movetext string) string {
+	withoutComments := strings.Builder{}
+	inBraceComment := false
+	inLineComment := false
+	for _, char := range movetext {
+		switch {
+		case inBraceComment:
+			inBraceComment = char != '}'
+		case inLineComment:
+			inLineComment = char != '\n'
+			if !inLineComment {
+				withoutComments.WriteRune(char)
+			}
+		case char == '{':
+			inBraceComment = true
+			withoutComments.WriteRune(' ')
+		case char == ';':
+			inLineComment = true
+		default:
+			withoutComments.WriteRune(char)
+		}
+	}
+	return withoutComments.String()
+}
+
 func parsePgnTag(g *Game, tag string) error {
+	name, value, err := splitPgnTag(tag)
+	if err != nil {
+		return err
+	}
+	if name == "Result" {
+		g.SetResult(parseResult(value))
+	}
+	if name == "FEN" {
+		pos, err := ParseFen(value)
+		if err != nil {
+			return fmt.Errorf("invalid FEN tag: %w", err)
+		}
+		result := g.GetResult()
+		termination, hasTermination := g.tags["Termination"]
+		if err := g.SetPosition(pos); err != nil {
+			return fmt.Errorf("invalid FEN tag: %w", err)
+		}
+		g.SetResult(result)
+		if hasTermination {
+			g.SetTag("Termination", termination)
+		}
+	}
+	g.SetTag(name, value)
+	return nil
+}
+
+// splitPgnTag returns the name and value of a tag pair line such as [Event "?"].
+func splitPgnTag(tag string) (name string, value string, err error) {
 	splitTag := strings.SplitN(tag[1:len(tag)-1], " ", 2)
 	if len(splitTag) != 2 {
-		return fmt.Errorf("invalid pgn tag: %s", tag)
+		return "", "", fmt.Errorf("invalid pgn tag: %s", tag)
+	}
+	return splitTag[0], strings.ReplaceAll(splitTag[1], "\"", ""), nil
+}
+
+// ScanPgnHeaders returns the tags of every game in r, in order, without parsing any moves. This is much faster than
+// reading each game with [ReadPgn] when only the tags are needed, such as for listing the games in a large file.
+func ScanPgnHeaders(r io.Reader) ([]map[string]string, error) {
+	reader := bufio.NewReader(r)
+	headers := []map[string]string{}
+	for {
+		gameText, err := readPgnGameText(reader)
+		if err == io.EOF {
+			return headers, nil
+		}
+		if err != nil {
+			return nil, fmt.Errorf("scan pgn headers failed: %w", err)
+		}
+		tags, err := pgnGameTags(gameText)
+		if err != nil {
+			return nil, fmt.Errorf("scan pgn headers failed: game %d: %w", len(headers)+1, err)
+		}
+		headers = append(headers, tags)
 	}
-	if splitTag[0] == "Result" {
-		g.SetResult(parseResult(strings.ReplaceAll(splitTag[1], "\"", "")))
+}
+
+// ReadPgnFunc reads every game in r for which keep returns true. keep is given the tags of each game before its moves
+// are parsed, so games that are filtered out cost little more than [ScanPgnHeaders]. An error is returned for any game
+// whose tags fail to parse, but a game whose moves fail to parse only causes an error if it was kept.
+func ReadPgnFunc(r io.Reader, keep func(tags map[string]string) bool) ([]*Game, error) {
+	reader := bufio.NewReader(r)
+	games := []*Game{}
+	for gameNumber := 1; ; gameNumber++ {
+		gameText, err := readPgnGameText(reader)
+		if err == io.EOF {
+			return games, nil
+		}
+		if err != nil {
+			return nil, fmt.Errorf("read pgn failed: %w", err)
+		}
+		tags, err := pgnGameTags(gameText)
+		if err != nil {
+			return nil, fmt.Errorf("read pgn failed: game %d: %w", gameNumber, err)
+		}
+		if !keep(tags) {
+			continue
+		}
+		game, err := ReadPgn(strings.NewReader(gameText))
+		if err != nil {
+			return nil, fmt.Errorf("game %d: %w", gameNumber, err)
+		}
+		games = append(games, game)
 	}
-	g.SetTag(splitTag[0], strings.ReplaceAll(splitTag[1], "\"", ""))
-	return nil
+}
+
+// pgnGameTags returns the tags at the start of gameText, which should come from [readPgnGameText].
+func pgnGameTags(gameText string) (map[string]string, error) {
+	tags := map[string]string{}
+	for _, line := range strings.Split(gameText, "\n") {
+		if !isPgnTagLine(line) {
+			break
+		}
+		name, value, err := splitPgnTag(strings.TrimSpace(line))
+		if err != nil {
+			return nil, err
+		}
+		tags[name] = value
+	}
+	return tags, nil
 }
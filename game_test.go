@@ -1,10 +1,12 @@
 package chess
 
 import (
+	"bytes"
 	"fmt"
 	"io"
 	"os"
 	"reflect"
+	"slices"
 	"strings"
 	"testing"
 	"time"
@@ -420,3 +422,1366 @@ func TestReadWritePgn(t *testing.T) {
 		}
 	}
 }
+
+func TestPly(t *testing.T) {
+	game := NewGame()
+	if game.Ply() != 0 {
+		t.Errorf("incorrect result: new game: expected 0, got %d", game.Ply())
+	}
+	game.MoveSan("e4")
+	game.MoveSan("e5")
+	game.MoveSan("Nf3")
+	if game.Ply() != 3 {
+		t.Errorf("incorrect result: after 3 moves: expected 3, got %d", game.Ply())
+	}
+	if game.Turn() != Black || game.FullMove() != 2 {
+		t.Errorf("incorrect result: after 3 moves: expected black on move 2, got %v on move %d", game.Turn(), game.FullMove())
+	}
+}
+
+func TestGameMoveInvalidPromotion(t *testing.T) {
+	game := NewGame()
+	position, _ := ParseFen("8/4P3/8/8/8/k7/8/K7 w - - 0 1")
+	game.SetPosition(position)
+	err := game.Move(Move{E7, E8, King})
+	if err == nil || !strings.Contains(err.Error(), "promotion") {
+		t.Errorf("incorrect result: input E7E8K: expected promotion error, got %v", err)
+	}
+	err = game.Move(Move{E7, E8, NoPieceType})
+	if err == nil || !strings.Contains(err.Error(), "promotion") {
+		t.Errorf("incorrect result: input E7E8: expected promotion error, got %v", err)
+	}
+	err = game.Move(Move{A1, A2, Queen})
+	if err == nil || !strings.Contains(err.Error(), "promotion") {
+		t.Errorf("incorrect result: input A1A2Q: expected promotion error, got %v", err)
+	}
+	err = game.Move(Move{E7, E8, Knight})
+	if err != nil {
+		t.Errorf("incorrect result: input E7E8N: expected nil, got %v", err)
+	}
+}
+
+func TestIndexPgnAndReadPgnAt(t *testing.T) {
+	pgns := []string{}
+	for _, fileName := range []string{"testPGNs/game_1.pgn", "testPGNs/game_2.pgn", "testPGNs/game_3.pgn"} {
+		fileBytes, err := os.ReadFile(fileName)
+		if err != nil {
+			t.Fatalf("failed to read file \"%s\"", fileName)
+		}
+		pgns = append(pgns, strings.ReplaceAll(string(fileBytes), "\r\n", "\n"))
+	}
+	reader := strings.NewReader(strings.Join(pgns, "\n\n"))
+
+	offsets, err := IndexPgn(reader)
+	if err != nil {
+		t.Fatalf("IndexPgn returned error: %v", err)
+	}
+	if len(offsets) != 3 {
+		t.Fatalf("incorrect result: expected 3 offsets, got %v", offsets)
+	}
+	if offsets[0] != 0 || offsets[1] != int64(len(pgns[0])+2) {
+		t.Errorf("incorrect result: expected offsets to start at 0 and %d, got %v", len(pgns[0])+2, offsets)
+	}
+
+	for i := len(offsets) - 1; i >= 0; i-- {
+		game, err := ReadPgnAt(reader, offsets[i])
+		if err != nil {
+			t.Fatalf("ReadPgnAt returned error for game %d: %v", i, err)
+		}
+		gameString := strings.Builder{}
+		WritePgn(game, &gameString)
+		expected, _ := ReadPgn(strings.NewReader(pgns[i]))
+		expectedString := strings.Builder{}
+		WritePgn(expected, &expectedString)
+		if gameString.String() != expectedString.String() {
+			t.Errorf("incorrect result for game %d: %s", i, cmp.Diff(expectedString.String(), gameString.String()))
+		}
+	}
+}
+
+func TestPositionAfterMove(t *testing.T) {
+	game := NewGame()
+	position, err := game.PositionAfterMove(Move{G1, F3, NoPieceType})
+	if err != nil {
+		t.Fatalf("incorrect result: input G1F3: expected nil, got %v", err)
+	}
+	if position.PieceAt(F3) != WhiteKnight || game.Ply() != 0 {
+		t.Errorf("incorrect result: expected knight on F3 and game unchanged, got %s", GenerateFen(position))
+	}
+	_, err = game.PositionAfterMove(Move{E2, E5, NoPieceType})
+	if err == nil {
+		t.Error("incorrect result: input E2E5: expected error, got nil")
+	}
+}
+
+func TestStartPosition(t *testing.T) {
+	game := NewGame()
+	game.MoveSan("e4")
+	if *game.StartPosition() != *getDefaultPosition() {
+		t.Errorf("incorrect result: expected default position, got %s", GenerateFen(game.StartPosition()))
+	}
+	if !game.IsFromStartingPosition() {
+		t.Error("incorrect result: new game should be from the starting position")
+	}
+
+	fen := "8/7p/3b2p1/2p2p2/3kpn2/7r/8/5K2 b - - 1 46"
+	position, _ := ParseFen(fen)
+	game.SetPosition(position)
+	game.MoveSan("Rh1+")
+	if GenerateFen(game.StartPosition()) != fen {
+		t.Errorf("incorrect result: expected %s, got %s", fen, GenerateFen(game.StartPosition()))
+	}
+	if game.IsFromStartingPosition() {
+		t.Error("incorrect result: game with custom FEN should not be from the starting position")
+	}
+}
+
+func TestThreeFoldPositions(t *testing.T) {
+	game := NewGame()
+	plies, ok := game.ThreeFoldPositions()
+	if ok || !slices.Equal(plies, []int{0}) {
+		t.Errorf("incorrect result: new game: expected [0] false, got %v %v", plies, ok)
+	}
+	for range 2 {
+		game.MoveSan("Nf3")
+		game.MoveSan("Nf6")
+		game.MoveSan("Ng1")
+		game.MoveSan("Ng8")
+	}
+	plies, ok = game.ThreeFoldPositions()
+	if !ok || !slices.Equal(plies, []int{0, 4, 8}) {
+		t.Errorf("incorrect result: expected [0 4 8] true, got %v %v", plies, ok)
+	}
+	game.MoveSan("e4")
+	plies, ok = game.ThreeFoldPositions()
+	if ok || !slices.Equal(plies, []int{9}) {
+		t.Errorf("incorrect result: after e4: expected [9] false, got %v %v", plies, ok)
+	}
+}
+
+func TestThreeFoldRepetitionIrreversibleMoves(t *testing.T) {
+	game := NewGame()
+	shuffle := func() {
+		game.MoveSan("Nf3")
+		game.MoveSan("Nf6")
+		game.MoveSan("Ng1")
+		game.MoveSan("Ng8")
+	}
+	shuffle()
+	game.MoveSan("e4")
+	game.MoveSan("e5")
+	shuffle()
+	if game.HasThreeFoldRepetition() {
+		t.Error("incorrect result: repetitions separated by pawn moves should not be combined")
+	}
+	shuffle()
+	plies, ok := game.ThreeFoldPositions()
+	if !ok || !slices.Equal(plies, []int{6, 10, 14}) {
+		t.Errorf("incorrect result: expected [6 10 14] true, got %v %v", plies, ok)
+	}
+	if !game.HasThreeFoldRepetition() {
+		t.Error("incorrect result: game should have three fold repetition")
+	}
+
+	game.MoveSan("Ke2")
+	game.MoveSan("Ke7")
+	game.MoveSan("Ke1")
+	game.MoveSan("Ke8")
+	plies, ok = game.ThreeFoldPositions()
+	if ok || !slices.Equal(plies, []int{18}) {
+		t.Errorf("incorrect result: after losing castling rights: expected [18] false, got %v %v", plies, ok)
+	}
+}
+
+func TestGameMoves(t *testing.T) {
+	game := NewGame()
+	if moves := game.Moves(); len(moves) != 0 {
+		t.Errorf("incorrect result: new game: expected no moves, got %v", moves)
+	}
+	game.MoveSan("e4")
+	game.MoveSan("e5")
+	expected := []Move{{E2, E4, NoPieceType}, {E7, E5, NoPieceType}}
+	moves := game.Moves()
+	if !slices.Equal(moves, expected) {
+		t.Errorf("incorrect result: expected %v, got %v", expected, moves)
+	}
+	moves[0] = Move{D2, D4, NoPieceType}
+	if game.moveHistory[0] != expected[0] {
+		t.Error("incorrect result: modifying the returned moves changed the game")
+	}
+}
+
+func TestNewGameWithTags(t *testing.T) {
+	game := NewGameWithTags(map[string]string{
+		"Site":   "example.com",
+		"Event":  "Club championship",
+		"Result": "1-0",
+	})
+	if tag, _ := game.GetTag("Site"); tag != "example.com" {
+		t.Errorf(`incorrect result: tag "Site": expected example.com, got %s`, tag)
+	}
+	if tag, _ := game.GetTag("Event"); tag != "Club championship" {
+		t.Errorf(`incorrect result: tag "Event": expected Club championship, got %s`, tag)
+	}
+	if tag, _ := game.GetTag("Round"); tag != "1" {
+		t.Errorf(`incorrect result: tag "Round": expected default 1, got %s`, tag)
+	}
+	if game.GetResult() != NoResult {
+		t.Errorf("incorrect result: Result tag should not be settable: got %v", game.GetResult())
+	}
+}
+
+func TestCanClaimDrawFiftyMove(t *testing.T) {
+	game := NewGame()
+	position, _ := ParseFen("4k3/8/8/8/8/8/8/R3K3 w - - 99 80")
+	game.SetPosition(position)
+	if game.CanClaimDrawFiftyMove() {
+		t.Error("incorrect result: half move 99: expected false, got true")
+	}
+	game.MoveSan("Ra2")
+	if !game.CanClaimDrawFiftyMove() {
+		t.Error("incorrect result: half move 100: expected true, got false")
+	}
+	if !game.CanClaimDraw() {
+		t.Error("incorrect result: CanClaimDraw should include the fifty move rule")
+	}
+}
+
+func TestResign(t *testing.T) {
+	game := NewGame()
+	game.MoveSan("e4")
+	game.Resign(Black)
+	if game.GetResult() != WhiteWins {
+		t.Errorf("incorrect result: expected %v, got %v", WhiteWins, game.GetResult())
+	}
+	if tag, _ := game.GetTag("Termination"); tag != "resignation" || game.Termination() != Resignation {
+		t.Errorf(`incorrect result: tag "Termination": expected resignation, got %s`, tag)
+	}
+	game.MoveSan("e5")
+	if game.GetResult() != WhiteWins {
+		t.Errorf("incorrect result: move after resignation reset result to %v", game.GetResult())
+	}
+	game.SetResult(BlackWins)
+	game.MoveSan("Nf3")
+	if game.GetResult() != NoResult {
+		t.Errorf("incorrect result: SetResult should not keep the result: got %v", game.GetResult())
+	}
+}
+
+func TestAgreeDraw(t *testing.T) {
+	game := NewGame()
+	game.AgreeDraw()
+	if tag, _ := game.GetTag("Termination"); tag != "agreement" || game.Termination() != Agreement {
+		t.Errorf(`incorrect result: tag "Termination": expected agreement, got %s`, tag)
+	}
+	game.MoveSan("f3")
+	game.MoveSan("e5")
+	game.MoveSan("g4")
+	if game.GetResult() != Draw {
+		t.Errorf("incorrect result: expected %v, got %v", Draw, game.GetResult())
+	}
+	game.MoveSan("Qh4#")
+	if game.GetResult() != BlackWins {
+		t.Errorf("incorrect result: checkmate should override agreed draw: got %v", game.GetResult())
+	}
+	if term := game.Termination(); term != Checkmate {
+		t.Errorf("incorrect result: checkmate should override agreed draw termination: got %v", term)
+	}
+}
+
+func TestReadPgnEnPassantMarker(t *testing.T) {
+	testCases := []string{
+		"1. e4 Nf6 2. e5 d5 3. exd6e.p. exd6 *",
+		"1. e4 Nf6 2. e5 d5 3. exd6 e.p. exd6 *",
+		"1.e4 Nf6 2.e5 d5 3.exd6e.p. exd6 *",
+	}
+	for _, movetext := range testCases {
+		game, err := ReadPgn(strings.NewReader("[Event \"?\"]\n\n" + movetext + "\n"))
+		if err != nil {
+			t.Errorf("incorrect result: input %s: expected nil error, got %v", movetext, err)
+			continue
+		}
+		expected := []Move{{E2, E4, NoPieceType}, {G8, F6, NoPieceType}, {E4, E5, NoPieceType}, {D7, D5, NoPieceType},
+			{E5, D6, NoPieceType}, {E7, D6, NoPieceType}}
+		if !slices.Equal(game.moveHistory, expected) {
+			t.Errorf("incorrect result: input %s: expected %v, got %v", movetext, expected, game.moveHistory)
+		}
+	}
+}
+
+func TestReadPgnResultPlacement(t *testing.T) {
+	tags := `[Event "?"]
+[Site "?"]
+[Date "????.??.??"]
+[Round "?"]
+[White "?"]
+[Black "?"]
+[Result "0-1"]
+
+`
+	game, err := ReadPgn(strings.NewReader(tags + "1. f3 e5 2. g4 Qh4#0-1"))
+	if err != nil {
+		t.Fatalf("ReadPgn returned error for result glued to last move: %v", err)
+	}
+	if game.Ply() != 4 || game.GetResult() != BlackWins {
+		t.Errorf("incorrect result: expected 4 plies and 0-1, got %d plies and %v", game.Ply(), game.GetResult())
+	}
+
+	game, err = ReadPgn(strings.NewReader(tags + "1. f3 e5 2. g4 Qh4#\n0-1\n"))
+	if err != nil {
+		t.Fatalf("ReadPgn returned error for result on its own line: %v", err)
+	}
+	if game.Ply() != 4 || game.GetResult() != BlackWins {
+		t.Errorf("incorrect result: expected 4 plies and 0-1, got %d plies and %v", game.Ply(), game.GetResult())
+	}
+
+	game, err = ReadPgn(strings.NewReader(strings.Replace(tags, "0-1", "1/2-1/2", 1) + "{Game drawn by agreement before any moves} 1/2-1/2"))
+	if err != nil {
+		t.Fatalf("ReadPgn returned error for comment followed by result: %v", err)
+	}
+	if game.Ply() != 0 || game.GetResult() != Draw {
+		t.Errorf("incorrect result: expected 0 plies and 1/2-1/2, got %d plies and %v", game.Ply(), game.GetResult())
+	}
+
+	game, err = ReadPgn(strings.NewReader(tags + "1. f3 {a weak move\nspanning lines} e5 ; the best reply\n2. g4 Qh4# 0-1"))
+	if err != nil {
+		t.Fatalf("ReadPgn returned error for movetext with comments: %v", err)
+	}
+	if game.Ply() != 4 {
+		t.Errorf("incorrect result: expected 4 plies, got %d", game.Ply())
+	}
+}
+
+func TestGameIsCheck(t *testing.T) {
+	game := NewGame()
+	game.MoveSan("e4")
+	game.MoveSan("f5")
+	if game.IsCheck() {
+		t.Error("incorrect result: expected false, got true")
+	}
+	game.MoveSan("Qh5+")
+	if !game.IsCheck() {
+		t.Error("incorrect result: expected true, got false")
+	}
+}
+
+func TestGameFromPositions(t *testing.T) {
+	fens := []string{
+		"r3k2r/8/8/3pP3/8/8/8/R3K2R w KQkq d6 0 1",
+		"r3k2r/8/3P4/8/8/8/8/R3K2R b KQkq - 0 1",
+		"2kr3r/8/3P4/8/8/8/8/R3K2R w KQ - 1 2",
+		"2kr3r/3P4/8/8/8/8/8/R3K2R b KQ - 0 2",
+		"2k4r/3r4/8/8/8/8/8/R3K2R w KQ - 0 3",
+	}
+	positions := []*Position{}
+	for _, fen := range fens {
+		position, _ := ParseFen(fen)
+		positions = append(positions, position)
+	}
+	game, err := GameFromPositions(positions)
+	if err != nil {
+		t.Fatalf("GameFromPositions returned error: %v", err)
+	}
+	expectedMoves := []Move{{E5, D6, NoPieceType}, {E8, C8, NoPieceType}, {D6, D7, NoPieceType}, {D8, D7, NoPieceType}}
+	if !slices.Equal(game.moveHistory, expectedMoves) {
+		t.Errorf("incorrect result: expected %v, got %v", expectedMoves, game.moveHistory)
+	}
+
+	before, _ := ParseFen("7k/1P6/8/8/8/8/8/K7 w - - 0 1")
+	after, _ := ParseFen("1N5k/8/8/8/8/8/8/K7 b - - 0 1")
+	game, err = GameFromPositions([]*Position{before, after})
+	if err != nil {
+		t.Fatalf("GameFromPositions returned error for promotion: %v", err)
+	}
+	if !slices.Equal(game.moveHistory, []Move{{B7, B8, Knight}}) {
+		t.Errorf("incorrect result: expected [B7B8N], got %v", game.moveHistory)
+	}
+
+	_, err = GameFromPositions([]*Position{positions[0], positions[2]})
+	if err == nil {
+		t.Error("incorrect result: unconnected positions: expected error, got nil")
+	}
+
+	for _, nilPositions := range [][]*Position{{nil}, {positions[0], nil}} {
+		if _, err := GameFromPositions(nilPositions); err == nil {
+			t.Errorf("incorrect result: nil position in %v: expected error, got nil", nilPositions)
+		}
+	}
+}
+
+func TestReadPgnNumericAnnotationGlyphs(t *testing.T) {
+	reader := strings.NewReader(`[Event "?"]
+[Site "?"]
+[Date "????.??.??"]
+[Round "?"]
+[White "?"]
+[Black "?"]
+[Result "*"]
+
+1. e4 $1 e5 $139 2. Nf3 $300 Nc6 $2 *`)
+	game, err := ReadPgn(reader)
+	if err != nil {
+		t.Fatalf("ReadPgn returned error for numeric annotation glyphs: %v", err)
+	}
+	if game.Ply() != 4 {
+		t.Errorf("incorrect result: expected 4 plies, got %d", game.Ply())
+	}
+}
+
+func TestFENHistory(t *testing.T) {
+	game := NewGame()
+	game.MoveSan("e4")
+	game.MoveSan("c5")
+	expected := []string{
+		DefaultFen,
+		"rnbqkbnr/pppppppp/8/8/4P3/8/PPPP1PPP/RNBQKBNR b KQkq e3 0 1",
+		"rnbqkbnr/pp1ppppp/8/2p5/4P3/8/PPPP1PPP/RNBQKBNR w KQkq c6 0 2",
+	}
+	if fens := game.FENHistory(); !slices.Equal(fens, expected) {
+		t.Errorf("incorrect result: %s", cmp.Diff(expected, fens))
+	}
+}
+
+func TestMoveWithSAN(t *testing.T) {
+	game := NewGame()
+	san, err := game.MoveWithSAN(Move{G1, F3, NoPieceType})
+	if err != nil || san != "Nf3" {
+		t.Errorf("incorrect result: input G1F3: expected Nf3, got %s, %v", san, err)
+	}
+	if game.Ply() != 1 || game.Turn() != Black {
+		t.Error("incorrect result: move was not played")
+	}
+	san, err = game.MoveWithSAN(Move{E2, E4, NoPieceType})
+	if err == nil || san != "" || game.Ply() != 1 {
+		t.Errorf("incorrect result: illegal move: expected error, got %s, %v", san, err)
+	}
+}
+
+func TestOnMove(t *testing.T) {
+	game := NewGame()
+	plies := []int{}
+	moves := []Move{}
+	fens := []string{}
+	game.OnMove(func(ply int, m Move, pos *Position) {
+		plies = append(plies, ply)
+		moves = append(moves, m)
+		fens = append(fens, GenerateFen(pos))
+	})
+	game.MoveSan("e4")
+	game.Move(Move{E7, E5, NoPieceType})
+	game.Move(Move{E4, E5, NoPieceType})
+	if !slices.Equal(plies, []int{1, 2}) {
+		t.Errorf("incorrect result: plies: expected [1 2], got %v", plies)
+	}
+	if !slices.Equal(moves, []Move{{E2, E4, NoPieceType}, {E7, E5, NoPieceType}}) {
+		t.Errorf("incorrect result: moves: expected [E2E4 E7E5], got %v", moves)
+	}
+	if fens[1] != "rnbqkbnr/pppp1ppp/8/4p3/4P3/8/PPPP1PPP/RNBQKBNR w KQkq e6 0 2" {
+		t.Errorf("incorrect result: position after E7E5: got %s", fens[1])
+	}
+}
+
+func TestGameFromSAN(t *testing.T) {
+	game, err := GameFromSAN("", []string{"e4", "e5", "Nf3"})
+	if err != nil {
+		t.Fatalf("GameFromSAN returned error: %v", err)
+	}
+	if game.Ply() != 3 || game.Turn() != Black {
+		t.Errorf("incorrect result: expected 3 plies with black to move, got %d plies", game.Ply())
+	}
+
+	game, err = GameFromSAN("4k3/8/8/8/8/8/8/R3K3 w Q - 0 1", []string{"O-O-O", "Kf7"})
+	if err != nil {
+		t.Fatalf("GameFromSAN returned error for custom FEN: %v", err)
+	}
+	if game.IsFromStartingPosition() || game.Ply() != 2 {
+		t.Errorf("incorrect result: expected custom start with 2 plies, got %d plies", game.Ply())
+	}
+
+	_, err = GameFromSAN("", []string{"e4", "e5", "Ke3"})
+	if err == nil || !strings.Contains(err.Error(), "move 2") {
+		t.Errorf("incorrect result: illegal third move: expected error mentioning move 2, got %v", err)
+	}
+
+	_, err = GameFromSAN("not a fen", []string{})
+	if err == nil {
+		t.Error("incorrect result: invalid FEN: expected error, got nil")
+	}
+}
+
+func TestTypedTags(t *testing.T) {
+	game := NewGame()
+	game.SetTag("WhiteElo", "2510")
+	game.SetTag("BlackElo", "?")
+	game.SetTag("ECO", "B20")
+	game.SetTag("PlyCount", "40")
+	if elo, ok := game.WhiteElo(); !ok || elo != 2510 {
+		t.Errorf("incorrect result: WhiteElo: expected 2510 true, got %d %v", elo, ok)
+	}
+	if elo, ok := game.BlackElo(); ok || elo != 0 {
+		t.Errorf("incorrect result: BlackElo: expected 0 false, got %d %v", elo, ok)
+	}
+	if eco, ok := game.ECO(); !ok || eco != "B20" {
+		t.Errorf("incorrect result: ECO: expected B20 true, got %s %v", eco, ok)
+	}
+	if plies, ok := game.IntTag("PlyCount"); !ok || plies != 40 {
+		t.Errorf("incorrect result: PlyCount: expected 40 true, got %d %v", plies, ok)
+	}
+	if _, ok := game.IntTag("TimeControl"); ok {
+		t.Error("incorrect result: missing tag: expected false, got true")
+	}
+}
+
+func TestLastMove(t *testing.T) {
+	game := NewGame()
+	if _, ok := game.LastMove(); ok {
+		t.Error("incorrect result: new game: expected false, got true")
+	}
+	game.MoveSan("d4")
+	game.MoveSan("Nf6")
+	if move, ok := game.LastMove(); !ok || move != (Move{G8, F6, NoPieceType}) {
+		t.Errorf("incorrect result: expected G8F6 true, got %v %v", move, ok)
+	}
+}
+
+func TestReadPgnLenientResult(t *testing.T) {
+	pgn := `[Event "?"]
+[Site "?"]
+[Date "????.??.??"]
+[Round "?"]
+[White "?"]
+[Black "?"]
+[Result "1-0"]
+
+1. e4 e5 1-0 garbage 1-0`
+	_, err := ReadPgn(strings.NewReader(pgn))
+	if err == nil {
+		t.Error("incorrect result: strict mode: expected error for movetext after result, got nil")
+	}
+	game, err := ReadPgnOpts(strings.NewReader(pgn), PgnReadOptions{Lenient: true})
+	if err != nil {
+		t.Fatalf("incorrect result: lenient mode: expected nil, got %v", err)
+	}
+	if game.Ply() != 2 || game.GetResult() != WhiteWins {
+		t.Errorf("incorrect result: expected 2 plies and 1-0, got %d plies and %v", game.Ply(), game.GetResult())
+	}
+}
+
+func TestGameMoveError(t *testing.T) {
+	game := NewGame()
+	testCases := []struct {
+		move   Move
+		reason string
+	}{
+		{Move{E4, E5, NoPieceType}, "no piece on E4"},
+		{Move{E7, E5, NoPieceType}, "not your turn"},
+		{Move{A1, A2, NoPieceType}, "can't capture your own piece"},
+		{Move{A1, A3, NoPieceType}, "path blocked"},
+		{Move{G1, G3, NoPieceType}, "N can't move from G1 to G3"},
+		{Move{E1, G1, NoPieceType}, "path blocked"},
+	}
+	for _, tc := range testCases {
+		err := game.MoveError(tc.move)
+		if err == nil || !strings.HasSuffix(err.Error(), tc.reason) {
+			t.Errorf("incorrect result: input %v: expected error ending in %q, got %v", tc.move, tc.reason, err)
+		}
+	}
+	if err := game.MoveError(Move{E2, E4, NoPieceType}); err != nil {
+		t.Errorf("incorrect result: input E2E4: expected nil, got %v", err)
+	}
+
+	pos, _ := ParseFen("4k3/8/8/8/8/8/8/R3K2r w Q - 0 1")
+	game.SetPosition(pos)
+	if err := game.MoveError(Move{E1, G1, NoPieceType}); err == nil || !strings.HasSuffix(err.Error(), "no castling rights") {
+		t.Errorf("incorrect result: input E1G1: expected no castling rights, got %v", err)
+	}
+	if err := game.MoveError(Move{E1, C1, NoPieceType}); err == nil || !strings.HasSuffix(err.Error(), "can't castle out of check") {
+		t.Errorf("incorrect result: input E1C1: expected can't castle out of check, got %v", err)
+	}
+	if err := game.MoveError(Move{E1, F1, NoPieceType}); err == nil || !strings.HasSuffix(err.Error(), "would leave king in check") {
+		t.Errorf("incorrect result: input E1F1: expected would leave king in check, got %v", err)
+	}
+}
+
+func TestWriteReducedTo(t *testing.T) {
+	game := NewGame()
+	game.SetTag("Annotator", "someone")
+	game.MoveSan("e4")
+	game.MoveSan("e5")
+	buf := &strings.Builder{}
+	n, err := game.WriteReducedTo(buf)
+	if err != nil {
+		t.Fatalf("incorrect result: expected nil error, got %v", err)
+	}
+	if n != int64(buf.Len()) {
+		t.Errorf("incorrect result: expected %d bytes, got %d", buf.Len(), n)
+	}
+	if strings.Contains(buf.String(), "Annotator") {
+		t.Errorf("incorrect result: reduced export contains non roster tag:\n%s", buf.String())
+	}
+	if !strings.HasSuffix(buf.String(), "\n\n1. e4 e5 *") {
+		t.Errorf("incorrect result: unexpected movetext:\n%s", buf.String())
+	}
+}
+
+func TestWritePgnReduced(t *testing.T) {
+	game1 := NewGame()
+	game1.MoveSan("d4")
+	game2 := NewGame()
+	game2.MoveSan("c4")
+	buf := &bytes.Buffer{}
+	if err := WritePgnReduced(buf, []*Game{game1, game2}); err != nil {
+		t.Fatalf("incorrect result: expected nil error, got %v", err)
+	}
+	r := bytes.NewReader(buf.Bytes())
+	offsets, err := IndexPgn(r)
+	if err != nil || len(offsets) != 2 {
+		t.Fatalf("incorrect result: expected 2 games, got %v, %v", offsets, err)
+	}
+	read, err := ReadPgnAt(r, offsets[1])
+	if err != nil || read.Ply() != 1 {
+		t.Errorf("incorrect result: second game: expected 1 ply, got %v, %v", read, err)
+	}
+}
+
+func TestReadPgnVerifyResults(t *testing.T) {
+	pgnTemplate := `[Event "?"]
+[Site "?"]
+[Date "????.??.??"]
+[Round "?"]
+[White "?"]
+[Black "?"]
+[Result "%s"]
+
+%s`
+	testCases := []struct {
+		result   string
+		moves    string
+		warnings int
+	}{
+		{"0-1", "1. f3 e5 2. g4 Qh4# 0-1", 0},
+		{"1-0", "1. f3 e5 2. g4 Qh4# 1-0", 1},
+		{"1-0", "1. e4 e5 1-0", 1},
+		{"1/2-1/2", "1. e4 e5 1/2-1/2", 1},
+		{"*", "1. e4 e5 *", 0},
+	}
+	for _, tc := range testCases {
+		warnings := []error{}
+		opts := PgnReadOptions{VerifyResults: func(warning error) { warnings = append(warnings, warning) }}
+		_, err := ReadPgnOpts(strings.NewReader(fmt.Sprintf(pgnTemplate, tc.result, tc.moves)), opts)
+		if err != nil {
+			t.Errorf("incorrect result: input %s: expected nil error, got %v", tc.moves, err)
+		}
+		if len(warnings) != tc.warnings {
+			t.Errorf("incorrect result: input %s: expected %d warnings, got %v", tc.moves, tc.warnings, warnings)
+		}
+	}
+}
+
+func TestGameValidate(t *testing.T) {
+	game := NewGame()
+	for _, san := range []string{"f3", "e5", "g4", "Qh4#"} {
+		game.MoveSan(san)
+	}
+	if err := game.Validate(); err != nil {
+		t.Errorf("incorrect result: expected nil, got %v", err)
+	}
+
+	corrupted := game.Copy()
+	corrupted.moveHistory[1] = Move{E7, E4, NoPieceType}
+	if err := corrupted.Validate(); err == nil {
+		t.Error("incorrect result: illegal move in history: expected error, got nil")
+	}
+
+	corrupted = game.Copy()
+	corrupted.position.SetPieceAt(A2, NoPiece)
+	if err := corrupted.Validate(); err == nil {
+		t.Error("incorrect result: position not matching history: expected error, got nil")
+	}
+
+	corrupted = game.Copy()
+	corrupted.SetResult(WhiteWins)
+	if err := corrupted.Validate(); err == nil {
+		t.Error("incorrect result: wrong result after checkmate: expected error, got nil")
+	}
+
+	corrupted = NewGame()
+	corrupted.tags["FEN"] = "8/8/8/8/8/8/8/8 w - - 0 1"
+	err := corrupted.Validate()
+	if err == nil || !strings.Contains(err.Error(), "invalid FEN tag") {
+		t.Errorf("incorrect result: FEN tag with no kings: expected invalid FEN tag error, got %v", err)
+	}
+}
+
+func TestReadPgnLenientTagInMovetext(t *testing.T) {
+	pgn := `[Event "?"]
+[Site "?"]
+[Date "????.??.??"]
+[Round "?"]
+[White "?"]
+[Black "?"]
+[Result "*"]
+
+1. e4 e5
+[TimeControl "300"]
+2. Nf3 *`
+	if _, err := ReadPgn(strings.NewReader(pgn)); err == nil {
+		t.Error("incorrect result: strict mode: expected error for tag in movetext, got nil")
+	}
+	game, err := ReadPgnOpts(strings.NewReader(pgn), PgnReadOptions{Lenient: true})
+	if err != nil {
+		t.Fatalf("incorrect result: lenient mode: expected nil, got %v", err)
+	}
+	if game.Ply() != 3 {
+		t.Errorf("incorrect result: expected 3 plies, got %d", game.Ply())
+	}
+}
+
+func TestMultiGamePgnTagInMovetext(t *testing.T) {
+	pgn := "[Event \"first\"]\n\n1. e4 e5\n[Foo \"x\"]\n2. Nf3 *\n\n[Event \"second\"]\n\n1. d4 1-0\n"
+	headers, err := ScanPgnHeaders(strings.NewReader(pgn))
+	if err != nil {
+		t.Fatalf("incorrect result: expected nil error, got %v", err)
+	}
+	if len(headers) != 2 || headers[0]["Event"] != "first" || headers[1]["Event"] != "second" {
+		t.Fatalf("incorrect result: expected the first and second games, got %v", headers)
+	}
+
+	offsets, err := IndexPgn(strings.NewReader(pgn))
+	if err != nil {
+		t.Fatalf("IndexPgn returned error: %v", err)
+	}
+	if len(offsets) != 2 || offsets[1] != int64(strings.Index(pgn, "[Event \"second\"]")) {
+		t.Fatalf("incorrect result: expected offsets of 2 games, got %v", offsets)
+	}
+
+	if _, err := ReadPgnAt(strings.NewReader(pgn), offsets[0]); err == nil {
+		t.Error("incorrect result: strict mode: expected error for tag in movetext, got nil")
+	}
+	game, err := ReadPgnOpts(strings.NewReader(pgn[:offsets[1]]), PgnReadOptions{Lenient: true})
+	if err != nil {
+		t.Fatalf("incorrect result: lenient mode: expected nil, got %v", err)
+	}
+	if game.Ply() != 3 {
+		t.Errorf("incorrect result: expected 3 plies, got %d", game.Ply())
+	}
+}
+
+func TestGameEnPassantSquare(t *testing.T) {
+	game := NewGame()
+	game.MoveSan("e4")
+	if game.EnPassantSquare() != E3 {
+		t.Errorf("incorrect result: after e4: expected E3, got %v", game.EnPassantSquare())
+	}
+
+	pos, _ := ParseFen("4k3/8/8/3pP3/8/8/8/4K3 w - - 0 1")
+	game.SetPosition(pos)
+	if err := game.SetEnPassantSquare(D6); err != nil {
+		t.Errorf("incorrect result: input D6: expected nil, got %v", err)
+	}
+	if fen, _ := game.GetTag("FEN"); fen != "4k3/8/8/3pP3/8/8/8/4K3 w - d6 0 1" {
+		t.Errorf("incorrect result: input D6: unexpected FEN tag %s", fen)
+	}
+	if !slices.Contains(game.LegalMoves(), Move{E5, D6, NoPieceType}) {
+		t.Error("incorrect result: input D6: expected exd6 to be legal")
+	}
+	if err := game.SetEnPassantSquare(E6); err == nil {
+		t.Error("incorrect result: input E6: expected error, got nil")
+	}
+	if game.EnPassantSquare() != D6 {
+		t.Errorf("incorrect result: after failed set: expected D6, got %v", game.EnPassantSquare())
+	}
+}
+
+func TestLegalMovesSAN(t *testing.T) {
+	game := NewGame()
+	sans := game.LegalMovesSAN()
+	if len(sans) != 20 || !slices.Contains(sans, "Nf3") || !slices.Contains(sans, "e4") {
+		t.Errorf("incorrect result: start position: got %v", sans)
+	}
+
+	pos, _ := ParseFen("k7/8/8/8/2Q5/2Q1Q3/8/K7 w - - 0 1")
+	game.SetPosition(pos)
+	sans = game.LegalMovesSAN()
+	seen := map[string]bool{}
+	for _, san := range sans {
+		if seen[san] {
+			t.Errorf("incorrect result: duplicate SAN %s in %v", san, sans)
+		}
+		seen[san] = true
+		if _, err := ParseSANMove(game.Position(), san); err != nil {
+			t.Errorf("incorrect result: SAN %s does not parse: %v", san, err)
+		}
+	}
+	if !slices.Contains(sans, "Qc3d4") {
+		t.Errorf("incorrect result: expected Qc3d4 in %v", sans)
+	}
+}
+
+func TestMatedColor(t *testing.T) {
+	game := NewGame()
+	if c, ok := game.MatedColor(); ok || c != NoColor {
+		t.Errorf("incorrect result: start position: expected NoColor false, got %v %v", c, ok)
+	}
+	for _, san := range []string{"f3", "e5", "g4", "Qh4#"} {
+		game.MoveSan(san)
+	}
+	if c, ok := game.MatedColor(); !ok || c != White {
+		t.Errorf("incorrect result: fool's mate: expected White true, got %v %v", c, ok)
+	}
+}
+
+func TestGameParseSAN(t *testing.T) {
+	game := NewGame()
+	move, err := game.ParseSAN("Nf3")
+	if err != nil || move != (Move{G1, F3, NoPieceType}) {
+		t.Errorf("incorrect result: input Nf3: expected %v, got %v, %v", Move{G1, F3, NoPieceType}, move, err)
+	}
+	if game.Ply() != 0 {
+		t.Errorf("incorrect result: expected the move not to be played, got %d plies", game.Ply())
+	}
+	if _, err := game.ParseSAN("Nf6"); err == nil {
+		t.Error("incorrect result: input Nf6: expected error, got nil")
+	}
+}
+
+func readAllTestPgns(t testing.TB) string {
+	all := strings.Builder{}
+	for i := 1; i <= 10; i++ {
+		data, err := os.ReadFile(fmt.Sprintf("testPGNs/game_%d.pgn", i))
+		if err != nil {
+			t.Fatalf("could not read test pgn: %v", err)
+		}
+		all.WriteString(strings.TrimSpace(string(data)) + "\n\n")
+	}
+	return all.String()
+}
+
+func TestConvertPgn(t *testing.T) {
+	pgns := readAllTestPgns(t)
+	games := []*Game{}
+	offsetsReader := strings.NewReader(pgns)
+	offsets, _ := IndexPgn(offsetsReader)
+	for _, offset := range offsets {
+		game, err := ReadPgnAt(offsetsReader, offset)
+		if err != nil {
+			t.Fatalf("could not read test pgn: %v", err)
+		}
+		games = append(games, game)
+	}
+
+	expected := &strings.Builder{}
+	WritePgnReduced(expected, games)
+	converted := &strings.Builder{}
+	if err := ConvertPgn(strings.NewReader(pgns), converted, true); err != nil {
+		t.Fatalf("incorrect result: expected nil error, got %v", err)
+	}
+	if converted.String() != expected.String() {
+		t.Errorf("incorrect result: reduced conversion differs from WritePgnReduced:\n%s", converted.String())
+	}
+
+	converted.Reset()
+	if err := ConvertPgn(strings.NewReader(pgns), converted, false); err != nil {
+		t.Fatalf("incorrect result: expected nil error, got %v", err)
+	}
+	headers, _ := ScanPgnHeaders(strings.NewReader(converted.String()))
+	if len(headers) != len(games) {
+		t.Errorf("incorrect result: expected %d converted games, got %d", len(games), len(headers))
+	}
+}
+
+func TestConvertPgnSkipsBadGames(t *testing.T) {
+	pgn := "[Event \"bad\"]\n\n1. e5 *\n\n[Event \"good\"]\n[FEN \"4k3/8/8/8/8/8/4P3/4K3 w - - 0 1\"]\n[Result \"*\"]\n\n1. e4 *\n"
+	converted := &strings.Builder{}
+	err := ConvertPgn(strings.NewReader(pgn), converted, true)
+	if err == nil || !strings.Contains(err.Error(), "game 1") {
+		t.Errorf("incorrect result: expected an error for game 1, got %v", err)
+	}
+	if strings.Contains(converted.String(), "bad") || !strings.HasSuffix(converted.String(), "\n1. e4 *\n") {
+		t.Errorf("incorrect result: expected only the good game, got:\n%s", converted.String())
+	}
+}
+
+func TestReadPgnFENTag(t *testing.T) {
+	pgn := "[Event \"?\"]\n[Result \"1-0\"]\n[SetUp \"1\"]\n[FEN \"4k3/8/8/8/8/8/4P3/4K3 b - - 0 16\"]\n\n16... Kd7 17. e4 1-0\n"
+	game, err := ReadPgn(strings.NewReader(pgn))
+	if err != nil {
+		t.Fatalf("incorrect result: expected nil error, got %v", err)
+	}
+	if fen := GenerateFen(game.Position()); fen != "8/3k4/8/8/4P3/8/8/4K3 b - e3 0 17" {
+		t.Errorf("incorrect result: unexpected position %s", fen)
+	}
+	if game.GetResult() != WhiteWins {
+		t.Errorf("incorrect result: expected result 1-0, got %v", game.GetResult())
+	}
+	if _, err := ReadPgn(strings.NewReader("[FEN \"bad\"]\n\n*\n")); err == nil {
+		t.Error("incorrect result: invalid FEN tag: expected error, got nil")
+	}
+
+	buf := &strings.Builder{}
+	WritePgn(game, buf)
+	reread, err := ReadPgn(strings.NewReader(buf.String()))
+	if err != nil {
+		t.Fatalf("incorrect result: reading written pgn: expected nil error, got %v", err)
+	}
+	if *reread.Position() != *game.Position() || !slices.Equal(reread.moveHistory, game.moveHistory) {
+		t.Errorf("incorrect result: round trip through WritePgn changed the game:\n%s", buf.String())
+	}
+}
+
+func TestScanPgnHeaders(t *testing.T) {
+	pgns := readAllTestPgns(t)
+	headers, err := ScanPgnHeaders(strings.NewReader(pgns))
+	if err != nil {
+		t.Fatalf("incorrect result: expected nil error, got %v", err)
+	}
+	if len(headers) != 10 {
+		t.Fatalf("incorrect result: expected 10 games, got %d", len(headers))
+	}
+	for i, tags := range headers {
+		data, _ := os.ReadFile(fmt.Sprintf("testPGNs/game_%d.pgn", i+1))
+		game, err := ReadPgn(strings.NewReader(string(data)))
+		if err != nil {
+			t.Fatalf("could not read test pgn %d: %v", i+1, err)
+		}
+		for _, tag := range []string{"Event", "White", "Black", "Result"} {
+			expected, _ := game.GetTag(tag)
+			if tags[tag] != expected {
+				t.Errorf("incorrect result: game %d tag %s: expected %s, got %s", i+1, tag, expected, tags[tag])
+			}
+		}
+	}
+}
+
+func BenchmarkScanPgnHeaders(b *testing.B) {
+	pgns := readAllTestPgns(b)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		ScanPgnHeaders(strings.NewReader(pgns))
+	}
+}
+
+func BenchmarkReadPgnAllGames(b *testing.B) {
+	pgns := readAllTestPgns(b)
+	r := strings.NewReader(pgns)
+	offsets, _ := IndexPgn(r)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		for _, offset := range offsets {
+			ReadPgnAt(r, offset)
+		}
+	}
+}
+
+func TestGameReplaceFrom(t *testing.T) {
+	game := NewGame()
+	for _, san := range []string{"e4", "e5", "Nf3", "Nc6"} {
+		game.MoveSan(san)
+	}
+	game.Resign(White)
+
+	err := game.ReplaceFrom(2, []Move{{F2, F3, NoPieceType}, {D8, D5, NoPieceType}})
+	if err == nil {
+		t.Error("incorrect result: illegal replacement: expected error, got nil")
+	}
+	if game.Ply() != 4 || game.GetResult() != BlackWins {
+		t.Errorf("incorrect result: after failed replacement: expected game unchanged, got %d plies, %v", game.Ply(), game.GetResult())
+	}
+
+	err = game.ReplaceFrom(2, []Move{{F1, C4, NoPieceType}})
+	if err != nil {
+		t.Fatalf("incorrect result: expected nil, got %v", err)
+	}
+	if game.Ply() != 3 || game.GetResult() != NoResult {
+		t.Errorf("incorrect result: expected 3 plies and *, got %d plies and %v", game.Ply(), game.GetResult())
+	}
+	if _, err := game.GetTag("Termination"); err == nil || game.Termination() != NoTermination {
+		t.Errorf("incorrect result: expected resignation termination to be cleared, got %v", game.Termination())
+	}
+	expected, _ := ParseFen("rnbqkbnr/pppp1ppp/8/4p3/2B1P3/8/PPPP1PPP/RNBQK1NR b KQkq - 1 2")
+	if *game.Position() != *expected {
+		t.Errorf("incorrect result: expected %s, got %s", GenerateFen(expected), GenerateFen(game.Position()))
+	}
+
+	if err := game.ReplaceFrom(4, nil); err == nil {
+		t.Error("incorrect result: ply out of range: expected error, got nil")
+	}
+	if err := game.ReplaceFrom(0, nil); err != nil || game.Ply() != 0 || *game.Position() != *getDefaultPosition() {
+		t.Errorf("incorrect result: ply 0: expected start position, got %v, %s", err, GenerateFen(game.Position()))
+	}
+}
+
+func TestGameReplaceFromAfterResign(t *testing.T) {
+	game := NewGame()
+	game.MoveSan("e4")
+	game.MoveSan("e5")
+	game.Resign(White)
+	if err := game.ReplaceFrom(1, nil); err != nil {
+		t.Fatalf("incorrect result: expected nil, got %v", err)
+	}
+	if game.GetResult() != NoResult || game.Termination() != NoTermination {
+		t.Errorf("incorrect result: expected * and %v, got %v and %v", NoTermination, game.GetResult(), game.Termination())
+	}
+
+	game.Resign(Black)
+	if err := game.ReplaceFrom(1, []Move{{F7, F6, NoPieceType}, {D2, D4, NoPieceType}, {G7, G5, NoPieceType}, {D1, H5, NoPieceType}}); err != nil {
+		t.Fatalf("incorrect result: expected nil, got %v", err)
+	}
+	if game.GetResult() != WhiteWins || game.Termination() != Checkmate {
+		t.Errorf("incorrect result: replacement ending in mate: expected 1-0 and %v, got %v and %v", Checkmate, game.GetResult(), game.Termination())
+	}
+}
+
+func TestGameTermination(t *testing.T) {
+	game := NewGame()
+	if term := game.Termination(); term != NoTermination {
+		t.Errorf("incorrect result: new game: expected %v, got %v", NoTermination, term)
+	}
+	game.SetResult(WhiteWins)
+	if term := game.Termination(); term != NoTermination {
+		t.Errorf("incorrect result: decisive result without a termination: expected %v, got %v", NoTermination, term)
+	}
+	game.SetTermination(Timeout)
+	if term := game.Termination(); term != Timeout {
+		t.Errorf("incorrect result: time forfeit: expected %v, got %v", Timeout, term)
+	}
+	if tag, _ := game.GetTag("Termination"); tag != "time forfeit" {
+		t.Errorf("incorrect result: expected Termination tag time forfeit, got %s", tag)
+	}
+	game.SetTermination(NoTermination)
+	if _, err := game.GetTag("Termination"); err == nil || game.Termination() != NoTermination {
+		t.Errorf("incorrect result: NoTermination should remove the tag, got %v", game.Termination())
+	}
+
+	game = NewGame()
+	for _, san := range []string{"f3", "e5", "g4", "Qh4#"} {
+		game.MoveSan(san)
+	}
+	if term := game.Termination(); term != Checkmate {
+		t.Errorf("incorrect result: fool's mate: expected %v, got %v", Checkmate, term)
+	}
+	game.SetTermination(Adjudication)
+	if term := game.Termination(); term != Adjudication {
+		t.Errorf("incorrect result: recorded termination should be kept over checkmate: expected %v, got %v", Adjudication, term)
+	}
+
+	pos, _ := ParseFen("k7/2Q5/1K6/8/8/8/8/8 b - - 0 1")
+	game.SetPosition(pos)
+	game.RemoveTag("Termination")
+	if term := game.Termination(); term != Stalemate {
+		t.Errorf("incorrect result: stalemate: expected %v, got %v", Stalemate, term)
+	}
+}
+
+func TestGameTerminationRoundTrip(t *testing.T) {
+	terminations := []Termination{Normal, Checkmate, Stalemate, Resignation, Timeout, InsufficientMaterial, FiftyMove,
+		Repetition, Agreement, Abandoned, Adjudication, RulesInfraction}
+	for _, termination := range terminations {
+		game := NewGame()
+		game.SetTermination(termination)
+		if term := game.Termination(); term != termination {
+			t.Errorf("incorrect result: SetTermination(%v): expected %v, got %v", termination, termination, term)
+		}
+		if term := game.Copy().Termination(); term != termination {
+			t.Errorf("incorrect result: copy of %v: expected %v, got %v", termination, termination, term)
+		}
+
+		buf := &strings.Builder{}
+		WritePgn(game, buf)
+		read, err := ReadPgn(strings.NewReader(buf.String()))
+		if err != nil {
+			t.Fatalf("could not read pgn for %v: %v", termination, err)
+		}
+		if term := read.Termination(); term != termination {
+			t.Errorf("incorrect result: pgn round trip of %v: expected %v, got %v", termination, termination, term)
+		}
+	}
+}
+
+func TestGameTerminationClearedWithResult(t *testing.T) {
+	game := NewGame()
+	game.MoveSan("e4")
+	game.Resign(Black)
+	game.SetResult(NoResult)
+	if _, err := game.GetTag("Termination"); err == nil || game.Termination() != NoTermination {
+		t.Errorf("incorrect result: result reset to *: expected %v, got %v", NoTermination, game.Termination())
+	}
+
+	game.SetTermination(Abandoned)
+	game.SetResult(NoResult)
+	if game.Termination() != Abandoned {
+		t.Errorf("incorrect result: termination of an unfinished game: expected %v, got %v", Abandoned, game.Termination())
+	}
+	game.SetResult(Draw)
+	game.SetTermination(Repetition)
+	game.SetResult(WhiteWins)
+	if game.Termination() != Repetition {
+		t.Errorf("incorrect result: changing a decisive result: expected %v, got %v", Repetition, game.Termination())
+	}
+
+	pgn := "[Result \"1-0\"]\n[Termination \"adjudication\"]\n[FEN \"4k3/8/8/8/8/8/4P3/4K3 w - - 0 1\"]\n\n1. e4 1-0\n"
+	read, err := ReadPgn(strings.NewReader(pgn))
+	if err != nil {
+		t.Fatalf("could not read pgn: %v", err)
+	}
+	if read.Termination() != Adjudication || read.GetResult() != WhiteWins {
+		t.Errorf("incorrect result: read pgn: expected 1-0 and %v, got %v and %v", Adjudication, read.GetResult(), read.Termination())
+	}
+}
+
+func TestTerminationNames(t *testing.T) {
+	for termination := NoTermination; termination <= RulesInfraction; termination++ {
+		if termination != NoTermination && termination.String() == "none" {
+			t.Errorf("incorrect result: %d has no name", termination)
+		}
+		tag, ok := terminationTag(termination)
+		if ok == (termination == NoTermination) {
+			t.Errorf("incorrect result: %v: unexpected tag %q %v", termination, tag, ok)
+		}
+		if ok && parseTermination(tag) != termination {
+			t.Errorf("incorrect result: tag %q parsed as %v, expected %v", tag, parseTermination(tag), termination)
+		}
+	}
+	if tag, _ := terminationTag(Timeout); tag != "time forfeit" || Timeout.String() != "timeout" {
+		t.Errorf("incorrect result: Timeout: expected tag time forfeit and name timeout, got %s and %s", tag, Timeout.String())
+	}
+}
+
+func TestGameTerminationFromTag(t *testing.T) {
+	testCases := []struct {
+		tag      string
+		expected Termination
+	}{
+		{"normal", Normal},
+		{"Normal", Normal},
+		{"time forfeit", Timeout},
+		{"adjudication", Adjudication},
+		{"rules infraction", RulesInfraction},
+		{"abandoned", Abandoned},
+		{"unterminated", NoTermination},
+	}
+	for _, tc := range testCases {
+		pgn := "[Result \"1-0\"]\n[Termination \"" + tc.tag + "\"]\n\n1. e4 1-0\n"
+		game, err := ReadPgn(strings.NewReader(pgn))
+		if err != nil {
+			t.Fatalf("could not read pgn: %v", err)
+		}
+		if term := game.Termination(); term != tc.expected {
+			t.Errorf("incorrect result: tag %s: expected %v, got %v", tc.tag, tc.expected, term)
+		}
+	}
+}
+
+func TestPlyToMoveNumber(t *testing.T) {
+	testCases := []struct {
+		startPly, ply int
+		fullMove      int
+		isWhite       bool
+	}{
+		{0, 0, 1, true},
+		{0, 1, 1, false},
+		{0, 2, 2, true},
+		{1, 0, 1, false},
+		{1, 1, 2, true},
+		{31, 0, 16, false},
+		{31, 1, 17, true},
+	}
+	for _, tc := range testCases {
+		fullMove, isWhite := PlyToMoveNumber(tc.startPly, tc.ply)
+		if fullMove != tc.fullMove || isWhite != tc.isWhite {
+			t.Errorf("incorrect result: input %d %d: expected %d %v, got %d %v", tc.startPly, tc.ply, tc.fullMove, tc.isWhite, fullMove, isWhite)
+		}
+	}
+}
+
+func TestWritePgnMoveNumbersFromFEN(t *testing.T) {
+	testCases := []struct {
+		fen      string
+		sans     []string
+		movetext string
+	}{
+		{DefaultFen, []string{"e4", "e5"}, "1. e4 e5 *"},
+		{"4k3/8/8/8/8/8/4P3/4K3 b - - 0 16", []string{"Kd7", "e4", "Kd6"}, "16... Kd7 17. e4 Kd6 *"},
+		{"4k3/8/8/8/8/8/4P3/4K3 w - - 0 16", []string{"e4", "Kd7", "e5"}, "16. e4 Kd7 17. e5 *"},
+		{"4k3/8/8/8/8/8/4P3/4K3 b - - 0 0", []string{"Kd7", "e4"}, "1... Kd7 2. e4 *"},
+	}
+	for _, tc := range testCases {
+		pos, _ := ParseFen(tc.fen)
+		game, err := GameFromSAN(tc.fen, tc.sans)
+		if err != nil {
+			t.Fatalf("could not create game from %s: %v", tc.fen, err)
+		}
+		buf := &strings.Builder{}
+		if err := WritePgn(game, buf); err != nil {
+			t.Fatalf("incorrect result: expected nil error, got %v", err)
+		}
+		_, movetext, _ := strings.Cut(buf.String(), "\n\n")
+		if movetext != tc.movetext {
+			t.Errorf("incorrect result: input %s: expected movetext %q, got %q", tc.fen, tc.movetext, movetext)
+		}
+		read, err := ReadPgn(strings.NewReader(buf.String()))
+		if err != nil || GenerateFen(read.StartPosition()) != GenerateFen(pos) || !slices.Equal(read.moveHistory, game.moveHistory) {
+			t.Errorf("incorrect result: input %s: written pgn did not read back: %v", tc.fen, err)
+		}
+	}
+}
+
+func TestWritePgnOptsZeroCastling(t *testing.T) {
+	game := NewGame()
+	pos, _ := ParseFen("r3k2r/8/8/8/8/8/8/R3K2R w KQkq - 0 1")
+	game.SetPosition(pos)
+	game.MoveSan("O-O")
+	game.MoveSan("O-O-O")
+	buf := &strings.Builder{}
+	if err := WritePgnOpts(game, buf, PgnWriteOptions{ZeroCastling: true}); err != nil {
+		t.Fatalf("incorrect result: expected nil error, got %v", err)
+	}
+	if !strings.HasSuffix(buf.String(), "\n1. 0-0 0-0-0 *") {
+		t.Errorf("incorrect result: unexpected movetext:\n%s", buf.String())
+	}
+
+	buf.Reset()
+	WritePgn(game, buf)
+	if !strings.HasSuffix(buf.String(), "\n1. O-O O-O-O *") {
+		t.Errorf("incorrect result: default should use the letter O:\n%s", buf.String())
+	}
+}
+
+func TestGameMoveWithAutoPromote(t *testing.T) {
+	game := NewGame()
+	pos, _ := ParseFen("8/1P6/8/7k/8/8/6p1/4K3 w - - 0 1")
+	game.SetPosition(pos)
+	if err := game.MoveWithAutoPromote(Move{B7, B8, NoPieceType}, NoPieceType); err != nil {
+		t.Fatalf("incorrect result: input B7B8: expected nil, got %v", err)
+	}
+	if game.Position().PieceAt(B8) != WhiteQueen {
+		t.Errorf("incorrect result: input B7B8: expected white queen on B8, got %v", game.Position().PieceAt(B8))
+	}
+	if err := game.MoveWithAutoPromote(Move{G2, G1, NoPieceType}, Knight); err != nil {
+		t.Fatalf("incorrect result: input G2G1: expected nil, got %v", err)
+	}
+	if game.Position().PieceAt(G1) != BlackKnight {
+		t.Errorf("incorrect result: input G2G1: expected black knight on G1, got %v", game.Position().PieceAt(G1))
+	}
+	if err := game.MoveWithAutoPromote(Move{E1, D1, NoPieceType}, King); err != nil {
+		t.Errorf("incorrect result: input E1D1: non promotion should be unaffected, got %v", err)
+	}
+}
+
+func TestGameParsedDate(t *testing.T) {
+	testCases := []struct {
+		date             string
+		year, month, day int
+		ok               bool
+		normalized       string
+	}{
+		{"2024.05.17", 2024, 5, 17, true, "2024.05.17"},
+		{"2024-05-17", 2024, 5, 17, true, "2024.05.17"},
+		{"2024/5/7", 2024, 5, 7, true, "2024.05.07"},
+		{"2024.??.??", 2024, 0, 0, true, "2024.??.??"},
+		{"2024-05", 2024, 5, 0, true, "2024.05.??"},
+		{"????.??.??", 0, 0, 0, true, "????.??.??"},
+		{"2024.13.01", 0, 0, 0, false, "2024.13.01"},
+		{"yesterday", 0, 0, 0, false, "yesterday"},
+	}
+	for _, tc := range testCases {
+		game := NewGame()
+		game.SetTag("Date", tc.date)
+		year, month, day, ok := game.ParsedDate()
+		if year != tc.year || month != tc.month || day != tc.day || ok != tc.ok {
+			t.Errorf("incorrect result: input %s: expected %d %d %d %v, got %d %d %d %v", tc.date, tc.year, tc.month, tc.day, tc.ok, year, month, day, ok)
+		}
+		game.NormalizeDate()
+		if date, _ := game.GetTag("Date"); date != tc.normalized {
+			t.Errorf("incorrect result: input %s: expected normalized %s, got %s", tc.date, tc.normalized, date)
+		}
+	}
+}
+
+func TestMergeGames(t *testing.T) {
+	a := NewGame()
+	a.SetTag("White", "Carlsen")
+	a.SetTag("Date", "2024.05.17")
+	for _, san := range []string{"e4", "e5", "Nf3"} {
+		a.MoveSan(san)
+	}
+	b := NewGame()
+	b.SetTag("Black", "Nakamura")
+	b.SetTag("Event", "Blitz")
+	b.SetTag("White", "M. Carlsen")
+	b.SetTag("Date", "????.??.??")
+	for _, san := range []string{"e4", "e5", "Nf3", "Nc6"} {
+		b.MoveSan(san)
+	}
+	b.Resign(White)
+
+	merged, err := MergeGames(a, b)
+	if err != nil {
+		t.Fatalf("incorrect result: expected nil error, got %v", err)
+	}
+	if merged.Ply() != 4 || merged.GetResult() != BlackWins {
+		t.Errorf("incorrect result: expected 4 plies and 0-1, got %d plies and %v", merged.Ply(), merged.GetResult())
+	}
+	expectedTags := map[string]string{"White": "M. Carlsen", "Black": "Nakamura", "Event": "Blitz", "Date": "2024.05.17"}
+	for tag, expected := range expectedTags {
+		if value, _ := merged.GetTag(tag); value != expected {
+			t.Errorf("incorrect result: tag %s: expected %s, got %s", tag, expected, value)
+		}
+	}
+
+	c := NewGame()
+	c.MoveSan("d4")
+	if _, err := MergeGames(a, c); err == nil {
+		t.Error("incorrect result: diverging games: expected error, got nil")
+	}
+}
+
+func TestReadPgnFunc(t *testing.T) {
+	pgns := readAllTestPgns(t)
+	headers, _ := ScanPgnHeaders(strings.NewReader(pgns))
+	expectedCount := 0
+	for _, tags := range headers {
+		if tags["Result"] == "1-0" {
+			expectedCount++
+		}
+	}
+	games, err := ReadPgnFunc(strings.NewReader(pgns), func(tags map[string]string) bool {
+		return tags["Result"] == "1-0"
+	})
+	if err != nil {
+		t.Fatalf("incorrect result: expected nil error, got %v", err)
+	}
+	if len(games) != expectedCount {
+		t.Fatalf("incorrect result: expected %d games, got %d", expectedCount, len(games))
+	}
+	for _, game := range games {
+		if game.GetResult() != WhiteWins {
+			t.Errorf("incorrect result: expected only white wins, got %v", game.GetResult())
+		}
+	}
+}
+
+func TestReadPgnFuncSkipsBadGames(t *testing.T) {
+	pgn := "[Event \"bad\"]\n\n1. e5 *\n\n[Event \"good\"]\n\n1. e4 *\n"
+	games, err := ReadPgnFunc(strings.NewReader(pgn), func(tags map[string]string) bool {
+		return tags["Event"] == "good"
+	})
+	if err != nil {
+		t.Fatalf("incorrect result: expected nil error, got %v", err)
+	}
+	if len(games) != 1 || games[0].Ply() != 1 {
+		t.Fatalf("incorrect result: expected the good game, got %v", games)
+	}
+	_, err = ReadPgnFunc(strings.NewReader(pgn), func(tags map[string]string) bool { return true })
+	if err == nil {
+		t.Error("incorrect result: expected an error for the kept bad game")
+	}
+}
+
+func TestReadPgnFuncBadTags(t *testing.T) {
+	pgn := "[Event \"good\"]\n\n1. e4 *\n\n[Event]\n\n1. d4 *\n"
+	_, err := ReadPgnFunc(strings.NewReader(pgn), func(tags map[string]string) bool { return false })
+	if err == nil {
+		t.Error("incorrect result: expected an error for the game with a bad tag")
+	}
+}
@@ -15,8 +15,12 @@ type Move struct {
 	Promotion  PieceType
 }
 
-// String returns a UCI-compatible string representation of the move. Format is Square1Square2Promotion
+// String returns a UCI-compatible string representation of the move. Format is Square1Square2Promotion. The zero Move
+// is written as "0000", the UCI null move.
 func (m Move) String() string {
+	if m == (Move{}) {
+		return "0000"
+	}
 	returnString := m.FromSquare.String() + m.ToSquare.String()
 	if m.Promotion != NoPieceType {
 		returnString += m.Promotion.String()
@@ -25,115 +29,150 @@ func (m Move) String() string {
 }
 
 // SanString converts a move to standard algebraic notation. It needs position information to do this. The position provided should be the position just before the move was made.
-// Expects that move and position are valid. Results are undefined otherwise.
+// If the move can't be written in SAN, such as when there is no piece of the side to move on its from square, the UCI
+// form, such as "e7e5", is returned instead. Use [Move.AppendSan] to get an error in that case.
 func (m Move) SanString(p *Position) string {
+	san, err := m.AppendSan(nil, p)
+	if err != nil {
+		return strings.ToLower(m.String())
+	}
+	return string(san)
+}
+
+// AppendSan appends the standard algebraic notation of m to dst and returns the extended buffer, like [Move.SanString]
+// but without allocating a new string for each move. p should be the position just before the move is made. An error
+// is returned, and dst is returned unchanged, if there isn't a piece of the side to move on m's from square.
+func (m Move) AppendSan(dst []byte, p *Position) ([]byte, error) {
+	if !isValidMove(m) || m.FromSquare == NoSquare || m.ToSquare == NoSquare {
+		return dst, fmt.Errorf("could not write SAN: invalid move %s", m)
+	}
 	piece := p.PieceAt(m.FromSquare)
+	if piece == NoPiece || piece.Color != p.Turn {
+		return dst, fmt.Errorf("could not write SAN: no piece of the side to move on %v", m.FromSquare)
+	}
 	if piece.Type == Pawn {
-		return sanStringPawn(m, p)
+		return appendSanPawn(dst, m, p), nil
 	}
 	if isCastleMove(p, m) {
-		return sanStringCastleMove(m)
+		return appendSanCastleMove(dst, m), nil
 	}
 
-	sanString := piece.Type.String()
-	sanString += resolveSanStringAmbiguity(m, p)
+	dst = append(dst, piece.Type.String()...)
+	dst = append(dst, resolveSanStringAmbiguity(m, p)...)
 	if p.PieceAt(m.ToSquare) != NoPiece {
-		sanString += "x"
+		dst = append(dst, 'x')
 	}
-	sanString += strings.ToLower(m.ToSquare.String())
+	dst = appendSanSquare(dst, m.ToSquare)
 
 	newPosition := *p
 	newPosition.Move(m)
 
-	if IsCheckMate(&newPosition) {
-		sanString += "#"
-		return sanString
-	}
-
-	if IsCheck(&newPosition) {
-		sanString += "+"
-		return sanString
-	}
-
-	return sanString
+	return append(dst, sanCheckSuffix(&newPosition)...), nil
 }
 
-func sanStringPawn(m Move, p *Position) string {
-	sanString := ""
-	if p.PieceAt(m.ToSquare) == NoPiece {
-		if p.EnPassant == m.ToSquare {
-			sanString += strings.ToLower(m.FromSquare.File.String()) + "x" + strings.ToLower(m.ToSquare.String())
-		} else {
-			sanString += strings.ToLower(m.ToSquare.String())
-		}
-	} else {
-		sanString += strings.ToLower(m.FromSquare.File.String()) + "x" + strings.ToLower(m.ToSquare.String())
+func appendSanPawn(dst []byte, m Move, p *Position) []byte {
+	if p.PieceAt(m.ToSquare) != NoPiece || p.EnPassant == m.ToSquare {
+		dst = append(dst, sanFile(m.FromSquare.File), 'x')
 	}
+	dst = appendSanSquare(dst, m.ToSquare)
 
 	if m.Promotion != NoPieceType {
-		sanString += "=" + m.Promotion.String()
+		dst = append(dst, '=')
+		dst = append(dst, m.Promotion.String()...)
 	}
 
 	newPosition := *p
 	newPosition.Move(m)
 
-	if IsCheckMate(&newPosition) {
-		sanString += "#"
-		return sanString
-	}
+	return append(dst, sanCheckSuffix(&newPosition)...)
+}
 
-	if IsCheck(&newPosition) {
-		sanString += "+"
-		return sanString
+// sanCheckSuffix returns "#" if p is checkmate, "+" if it is check, and "" otherwise. p is the position after the move.
+func sanCheckSuffix(p *Position) string {
+	if !IsCheck(p) {
+		return ""
 	}
-
-	return sanString
+	if p.HasLegalMove() {
+		return "+"
+	}
+	return "#"
 }
 
-func sanStringCastleMove(m Move) string {
+func appendSanCastleMove(dst []byte, m Move) []byte {
 	if m.ToSquare.File == FileG {
-		return "O-O"
+		return append(dst, "O-O"...)
 	}
 	if m.ToSquare.File == FileC {
-		return "O-O-O"
+		return append(dst, "O-O-O"...)
 	}
-	return "O?O"
+	return append(dst, "O?O"...)
 }
 
 func resolveSanStringAmbiguity(m Move, p *Position) string {
-	piece := p.PieceAt(m.FromSquare)
-	validMoves := GenerateLegalMoves(p)
+	needsFile, needsRank := m.NeedsDisambiguation(p)
 	disambiguator := ""
-	hasFile := false
-	hasRank := false
-	for _, move := range validMoves {
-		if move == m {
+	if needsFile {
+		disambiguator += m.FromSquare.File.String()
+	}
+	if needsRank {
+		disambiguator += m.FromSquare.Rank.String()
+	}
+	return strings.ToLower(disambiguator)
+}
+
+// appendSanSquare appends s in lower case, such as "e4".
+func appendSanSquare(dst []byte, s Square) []byte {
+	return append(dst, sanFile(s.File), sanRank(s.Rank))
+}
+
+func sanFile(f File) byte {
+	return byte('a' + f - FileA)
+}
+
+func sanRank(r Rank) byte {
+	return byte('0' + r)
+}
+
+// NeedsDisambiguation reports whether the SAN form of m needs the file and/or rank of its from square to be
+// distinguished from other legal moves of the same piece type to the same square. Moves that would be illegal, such as
+// those by pinned pieces, are not considered. As specified by the PGN standard the file is preferred, then the rank,
+// and then both if neither alone is enough. p should be the position just before the move is made.
+func (m Move) NeedsDisambiguation(p *Position) (file bool, rank bool) {
+	piece := p.PieceAt(m.FromSquare)
+	ambiguous := false
+	sharesFile := false
+	sharesRank := false
+	for _, move := range GenerateLegalMoves(p) {
+		if move.FromSquare == m.FromSquare || move.ToSquare != m.ToSquare || p.PieceAt(move.FromSquare) != piece {
 			continue
 		}
-		if move.ToSquare == m.ToSquare {
-			possibleAmbiguousPiece := p.PieceAt(move.FromSquare)
-			if possibleAmbiguousPiece == piece {
-				// different files
-				if move.FromSquare.File != m.FromSquare.File && !hasFile {
-					disambiguator = m.FromSquare.File.String() + disambiguator
-					hasFile = true
-				} else if move.FromSquare.Rank != m.FromSquare.Rank && !hasRank {
-					// different rank
-					disambiguator += m.FromSquare.Rank.String()
-					hasRank = true
-				}
-
-				if hasFile && hasRank {
-					break
-				}
-			}
+		ambiguous = true
+		if move.FromSquare.File == m.FromSquare.File {
+			sharesFile = true
+		}
+		if move.FromSquare.Rank == m.FromSquare.Rank {
+			sharesRank = true
 		}
 	}
-	return strings.ToLower(disambiguator)
+	if !ambiguous {
+		return false, false
+	}
+	if !sharesFile {
+		return true, false
+	}
+	if !sharesRank {
+		return false, true
+	}
+	return true, true
 }
 
 // ParseUCIMove expects a UCI compatible move string. Format should be Square1Square2Promotion, where promotion is optional.
+// The promotion may be upper or lower case, and must be a rook, knight, bishop, or queen. The UCI null move "0000"
+// gives the zero Move.
 func ParseUCIMove(s string) (Move, error) {
+	if s == "0000" {
+		return Move{}, nil
+	}
 	if len(s) != 4 && len(s) != 5 {
 		return Move{}, fmt.Errorf("invalid move string: string not 4 or 5 characters long: %s", s)
 	}
@@ -151,21 +190,31 @@ func ParseUCIMove(s string) (Move, error) {
 		if err != nil {
 			return Move{}, fmt.Errorf("invalid move string: %w", err)
 		}
+		if !isValidPromotion(promotion) {
+			return Move{}, fmt.Errorf("invalid move string: can't promote to %v: %s", promotion, s)
+		}
 	}
 
 	return Move{fromSquare, toSquare, promotion}, nil
 }
 
 // ParseSANMove returns a move given a position and an SAN formatted move. SAN format defined here: http://www.saremba.de/chessgml/standards/pgn/pgn-complete.htm#c8.2.3
+// Check and mate symbols, annotation glyphs such as "!?", numeric annotation glyphs such as "$1", and en passant markers
+// ("e.p.") are ignored. Castling may be written with the digit zero ("0-0" and "0-0-0") as well as the letter O.
 func ParseSANMove(p *Position, s string) (Move, error) {
-	cleanedString := strings.ReplaceAll(s, "+", "")
-	cleanedString = strings.ReplaceAll(cleanedString, "#", "")
+	cleanedString := cleanSANString(s)
 
 	if p.Turn != White && p.Turn != Black {
 		return Move{}, errors.New("could not parse SAN move: position turn is not set to white or black")
 	}
 
-	if s == "O-O" || s == "O-O-O" {
+	switch cleanedString {
+	case "0-0":
+		cleanedString = "O-O"
+	case "0-0-0":
+		cleanedString = "O-O-O"
+	}
+	if cleanedString == "O-O" || cleanedString == "O-O-O" {
 		return parseSANCastleMove(p, cleanedString)
 	}
 	if isSANBasicPawnMove(p, cleanedString) {
@@ -190,6 +239,21 @@ func ParseSANMove(p *Position, s string) (Move, error) {
 	return Move{}, errors.New("could not parse SAN move: input, " + s)
 }
 
+// cleanSANString removes everything from s that doesn't affect which move it represents.
+func cleanSANString(s string) string {
+	if index := strings.IndexRune(s, '$'); index >= 0 {
+		s = s[:index]
+	}
+	s = strings.Map(func(r rune) rune {
+		if r == '+' || r == '#' || r == '!' || r == '?' {
+			return -1
+		}
+		return r
+	}, s)
+	s = strings.TrimSuffix(strings.TrimSpace(s), "e.p.")
+	return strings.TrimSpace(s)
+}
+
 func isSANBasicPawnMove(p *Position, s string) bool {
 	return len(s) == 2 &&
 		!(rune(s[1]) == '8' && p.Turn == White) &&
@@ -301,6 +365,9 @@ func parseSANPromotion(p *Position, s string) (Move, error) {
 		return Move{}, fmt.Errorf("could not parse move before promotion: %w", err)
 	}
 
+	if len(s)-len(sNoPromotion) != 2 {
+		return Move{}, fmt.Errorf("could not parse SAN promotion: expected one piece after '=': input %s", s)
+	}
 	promotion, err := parsePieceType(rune(s[len(s)-1]))
 	if err != nil {
 		return Move{}, fmt.Errorf("could not parse SAN promotion: input %s: %w", s, err)
@@ -350,6 +417,36 @@ func parseSANPieceMove(p *Position, s string) (Move, error) {
 	return move, err
 }
 
+// AmbiguousSANError is returned by [ParseSANMove] when more than one legal move matches a SAN move, such as "Nd2" when
+// both knights can reach d2.
+type AmbiguousSANError struct {
+	// Candidates are the legal moves that match the SAN move.
+	Candidates []Move
+}
+
+func (e *AmbiguousSANError) Error() string {
+	return fmt.Sprintf("invalid SAN move: ambiguous move, could be any of %v", e.Candidates)
+}
+
+// resolveAmbiguousSANMove returns the only legal move in candidates. An [*AmbiguousSANError] is returned if more than
+// one of them is legal.
+func resolveAmbiguousSANMove(p *Position, candidates []Move) (Move, error) {
+	legalCandidates := []Move{}
+	for _, move := range GenerateLegalMoves(p) {
+		if slices.Contains(candidates, move) {
+			legalCandidates = append(legalCandidates, move)
+		}
+	}
+	switch len(legalCandidates) {
+	case 0:
+		return Move{}, fmt.Errorf("invalid SAN move: none of %v are legal", candidates)
+	case 1:
+		return legalCandidates[0], nil
+	default:
+		return Move{}, &AmbiguousSANError{Candidates: legalCandidates}
+	}
+}
+
 // TODO reduce repetition
 func parseSANRookMove(p *Position, toSquare Square) (Move, error) {
 	isAmbiguous := false
@@ -409,16 +506,7 @@ func parseSANRookMove(p *Position, toSquare Square) (Move, error) {
 		}
 	}
 	if isAmbiguous {
-		legalMoves := GenerateLegalMoves(p)
-		moveIWant := Move{}
-		for _, move := range legalMoves {
-			if moveIWant.FromSquare == NoSquare && slices.Contains(ambiguousMoves, move) {
-				moveIWant = move
-			} else if moveIWant.FromSquare != NoSquare && slices.Contains(ambiguousMoves, move) {
-				return Move{}, fmt.Errorf("invalid SAN rook move: ambiguous move (multiple possible pieces)")
-			}
-		}
-		return moveIWant, nil
+		return resolveAmbiguousSANMove(p, ambiguousMoves)
 	}
 	if fromSquare == NoSquare {
 		return Move{}, fmt.Errorf("invalid SAN rook move: could not find piece to move")
@@ -500,16 +588,7 @@ func parseSANKnightMove(p *Position, toSquare Square) (Move, error) {
 		fromSquare = currentSquare
 	}
 	if isAmbiguous {
-		legalMoves := GenerateLegalMoves(p)
-		moveIWant := Move{}
-		for _, move := range legalMoves {
-			if moveIWant.FromSquare == NoSquare && slices.Contains(ambiguousMoves, move) {
-				moveIWant = move
-			} else if moveIWant.FromSquare != NoSquare && slices.Contains(ambiguousMoves, move) {
-				return Move{}, fmt.Errorf("invalid SAN knight move: ambiguous move (multiple possible pieces)")
-			}
-		}
-		return moveIWant, nil
+		return resolveAmbiguousSANMove(p, ambiguousMoves)
 	}
 	if fromSquare == NoSquare {
 		return Move{}, fmt.Errorf("invalid SAN knight move: could not find piece to move")
@@ -576,16 +655,7 @@ func parseSANBishopMove(p *Position, toSquare Square) (Move, error) {
 		}
 	}
 	if isAmbiguous {
-		legalMoves := GenerateLegalMoves(p)
-		moveIWant := Move{}
-		for _, move := range legalMoves {
-			if moveIWant.FromSquare == NoSquare && slices.Contains(ambiguousMoves, move) {
-				moveIWant = move
-			} else if moveIWant.FromSquare != NoSquare && slices.Contains(ambiguousMoves, move) {
-				return Move{}, fmt.Errorf("invalid SAN bishop move: ambiguous move (multiple possible pieces)")
-			}
-		}
-		return moveIWant, nil
+		return resolveAmbiguousSANMove(p, ambiguousMoves)
 	}
 	if fromSquare == NoSquare {
 		return Move{}, fmt.Errorf("invalid SAN bishop move: could not find piece to move")
@@ -708,16 +778,7 @@ func parseSANQueenMove(p *Position, toSquare Square) (Move, error) {
 		}
 	}
 	if isAmbiguous {
-		legalMoves := GenerateLegalMoves(p)
-		moveIWant := Move{}
-		for _, move := range legalMoves {
-			if moveIWant.FromSquare == NoSquare && slices.Contains(ambiguousMoves, move) {
-				moveIWant = move
-			} else if moveIWant.FromSquare != NoSquare && slices.Contains(ambiguousMoves, move) {
-				return Move{}, fmt.Errorf("invalid SAN queen move: ambiguous move (multiple possible pieces)")
-			}
-		}
-		return moveIWant, nil
+		return resolveAmbiguousSANMove(p, ambiguousMoves)
 	}
 	if fromSquare == NoSquare {
 		return Move{}, fmt.Errorf("invalid SAN Queen move: could not find piece to move")
@@ -1349,7 +1410,7 @@ func findBishopFromSquareRank(p *Position, toSquare Square, r Rank) Square {
 			}
 			break
 		}
-		for currentSquare := squareToLeft(squareBelow(toSquare)); currentSquare != NoSquare; currentSquare = squareToLeft(squareBelow(currentSquare)) {
+		for currentSquare := squareToRight(squareAbove(toSquare)); currentSquare != NoSquare; currentSquare = squareToRight(squareAbove(currentSquare)) {
 			piece := p.PieceAt(currentSquare)
 			if piece == NoPiece {
 				continue
@@ -1366,7 +1427,7 @@ func findBishopFromSquareRank(p *Position, toSquare Square, r Rank) Square {
 			break
 		}
 	} else if diff > Rank8 {
-		for currentSquare := squareToRight(squareAbove(toSquare)); currentSquare != NoSquare; currentSquare = squareToRight(squareAbove(currentSquare)) {
+		for currentSquare := squareToLeft(squareBelow(toSquare)); currentSquare != NoSquare; currentSquare = squareToLeft(squareBelow(currentSquare)) {
 			piece := p.PieceAt(currentSquare)
 			if piece == NoPiece {
 				continue
@@ -1461,7 +1522,7 @@ func findQueenFromSquareRank(p *Position, toSquare Square, r Rank) Square {
 			}
 			break
 		}
-		for currentSquare := squareToLeft(squareBelow(toSquare)); currentSquare != NoSquare; currentSquare = squareToLeft(squareBelow(currentSquare)) {
+		for currentSquare := squareToRight(squareAbove(toSquare)); currentSquare != NoSquare; currentSquare = squareToRight(squareAbove(currentSquare)) {
 			piece := p.PieceAt(currentSquare)
 			if piece == NoPiece {
 				continue
@@ -1478,7 +1539,7 @@ func findQueenFromSquareRank(p *Position, toSquare Square, r Rank) Square {
 			break
 		}
 	} else if diff > Rank8 {
-		for currentSquare := squareToRight(squareAbove(toSquare)); currentSquare != NoSquare; currentSquare = squareToRight(squareAbove(currentSquare)) {
+		for currentSquare := squareToLeft(squareBelow(toSquare)); currentSquare != NoSquare; currentSquare = squareToLeft(squareBelow(currentSquare)) {
 			piece := p.PieceAt(currentSquare)
 			if piece == NoPiece {
 				continue
@@ -1638,9 +1699,66 @@ func parseSANPieceCapture(p *Position, s string) (Move, error) {
 	return Move{}, fmt.Errorf("could not parse SAN move: failed to disambiguate rank or file: input %s", s)
 }
 
-// IsValidMove makes sure each of the elements in Move m are logical. Namely that the squares can be found on a chess board.
+// IsValidMove makes sure each of the elements in Move m are logical. Namely that the squares can be found on a chess board,
+// and that the promotion is either empty or a piece a pawn can promote to.
 func isValidMove(m Move) bool {
 	return isValidSquare(m.FromSquare) && m.FromSquare != NoSquare &&
 		isValidSquare(m.ToSquare) && m.ToSquare != NoSquare &&
-		isValidPieceType(m.Promotion)
+		isValidPromotion(m.Promotion)
+}
+
+func isValidPromotion(pt PieceType) bool {
+	return pt == NoPieceType || pt == Rook || pt == Knight || pt == Bishop || pt == Queen
+}
+
+// checkPromotion returns an error if m promotes when it shouldn't, doesn't promote when it should, or promotes to a
+// piece other than a rook, knight, bishop, or queen.
+func checkPromotion(p *Position, m Move) error {
+	if !isValidPromotion(m.Promotion) {
+		return fmt.Errorf("invalid promotion: can't promote to %v: move %s", m.Promotion, m)
+	}
+	piece := p.PieceAt(m.FromSquare)
+	reachesLastRank := piece.Type == Pawn &&
+		((piece.Color == White && m.ToSquare.Rank == Rank8) || (piece.Color == Black && m.ToSquare.Rank == Rank1))
+	if m.Promotion != NoPieceType && !reachesLastRank {
+		return fmt.Errorf("invalid promotion: only a pawn reaching the last rank can promote: move %s", m)
+	}
+	if m.Promotion == NoPieceType && reachesLastRank {
+		return fmt.Errorf("invalid promotion: pawn reaching the last rank must promote: move %s", m)
+	}
+	return nil
+}
+
+// VariationSAN converts a sequence of moves played from start into SAN. An error is returned if any move is not legal
+// in the position it is played from. start is not modified.
+func VariationSAN(start *Position, moves []Move) ([]string, error) {
+	position := *start
+	sanMoves := make([]string, 0, len(moves))
+	for i, move := range moves {
+		if !slices.Contains(GenerateLegalMoves(&position), move) {
+			return nil, fmt.Errorf("move %d, %s, is not legal", i, move)
+		}
+		sanMoves = append(sanMoves, move.SanString(&position))
+		position.Move(move)
+	}
+	return sanMoves, nil
+}
+
+// MoveBetween returns the legal move that turns before into after, including castling, en passant, and promotions. Move
+// counters are ignored, as is the en passant square when no en passant capture is legal. An error is returned unless
+// exactly one legal move connects the positions.
+func MoveBetween(before *Position, after *Position) (Move, error) {
+	matches := []Move{}
+	for _, move := range GenerateLegalMoves(before) {
+		if positionsEqualNoMoveCounter(before.AfterMove(move), after) {
+			matches = append(matches, move)
+		}
+	}
+	if len(matches) == 0 {
+		return Move{}, errors.New("no legal move connects the positions")
+	}
+	if len(matches) > 1 {
+		return Move{}, fmt.Errorf("multiple legal moves connect the positions: %v", matches)
+	}
+	return matches[0], nil
 }
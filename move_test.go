@@ -1,6 +1,8 @@
 package chess
 
 import (
+	"errors"
+	"slices"
 	"testing"
 )
 
@@ -39,6 +41,19 @@ func TestParseUCIMove(t *testing.T) {
 	}
 }
 
+func TestParseUCIMoveNullMove(t *testing.T) {
+	move, err := ParseUCIMove("0000")
+	if err != nil || move != (Move{}) {
+		t.Errorf("incorrect result: input 0000: expected zero move, got %v %v", move, err)
+	}
+	if str := (Move{}).String(); str != "0000" {
+		t.Errorf("incorrect result: zero move: expected 0000, got %s", str)
+	}
+	if _, err := ParseUCIMove("000"); err == nil {
+		t.Error("incorrect result: input 000: expected error, got nil")
+	}
+}
+
 func TestParseSANMovePawn(t *testing.T) {
 	pos := getDefaultPosition()
 	moveString := "e4"
@@ -745,6 +760,27 @@ func TestParseSANMoveCastling(t *testing.T) {
 	}
 }
 
+func TestParseSANMoveCastlingZeros(t *testing.T) {
+	pos := getDefaultPosition()
+	testCases := []struct {
+		turn     Color
+		input    string
+		expected Move
+	}{
+		{White, "0-0", Move{E1, G1, NoPieceType}},
+		{White, "0-0-0+", Move{E1, C1, NoPieceType}},
+		{Black, "0-0", Move{E8, G8, NoPieceType}},
+		{Black, "0-0-0", Move{E8, C8, NoPieceType}},
+	}
+	for _, tc := range testCases {
+		pos.Turn = tc.turn
+		move, err := ParseSANMove(pos, tc.input)
+		if err != nil || move != tc.expected {
+			t.Errorf("incorrect result: input %s: expected %v, got %v %v", tc.input, tc.expected, move, err)
+		}
+	}
+}
+
 func TestParseSANMoveIgnoresOtherSymbols(t *testing.T) {
 	pos := &Position{}
 	pos.Turn = White
@@ -780,3 +816,265 @@ func TestResolveSanStringAmbiguity(t *testing.T) {
 		t.Errorf("incorrect result: expected %v, got %v", "C3", result)
 	}
 }
+
+func TestParseSANMoveIgnoresAnnotations(t *testing.T) {
+	pos := getDefaultPosition()
+	for _, moveString := range []string{"Nf3!?", "Nf3$1", "Nf3!! $3", "Nf3+?"} {
+		move, err := ParseSANMove(pos, moveString)
+		if err != nil || move != (Move{G1, F3, NoPieceType}) {
+			t.Errorf("incorrect result: input %s: expected %v, got %v, %v", moveString, Move{G1, F3, NoPieceType}, move, err)
+		}
+	}
+
+	pos, _ = ParseFen("rnbqkbnr/ppp1p1pp/8/3pPp2/8/8/PPPP1PPP/RNBQKBNR w KQkq d6 0 3")
+	for _, moveString := range []string{"exd6e.p.", "exd6 e.p.", "exd6e.p.+"} {
+		move, err := ParseSANMove(pos, moveString)
+		if err != nil || move != (Move{E5, D6, NoPieceType}) {
+			t.Errorf("incorrect result: input %s: expected %v, got %v, %v", moveString, Move{E5, D6, NoPieceType}, move, err)
+		}
+	}
+
+	pos, _ = ParseFen("4k3/8/8/8/8/8/8/4K2R w K - 0 1")
+	move, err := ParseSANMove(pos, "O-O+")
+	if err != nil || move != (Move{E1, G1, NoPieceType}) {
+		t.Errorf("incorrect result: input O-O+: expected %v, got %v, %v", Move{E1, G1, NoPieceType}, move, err)
+	}
+}
+
+func TestNeedsDisambiguation(t *testing.T) {
+	pos, _ := ParseFen("k7/8/8/8/2Q5/2Q1Q3/8/K7 w - - 0 1")
+	file, rank := Move{C3, D4, NoPieceType}.NeedsDisambiguation(pos)
+	if !file || !rank {
+		t.Errorf("incorrect result: C3D4: expected true true, got %v %v", file, rank)
+	}
+
+	pos, _ = ParseFen("k7/8/8/8/8/R7/8/K6R w - - 0 1")
+	file, rank = Move{A3, A2, NoPieceType}.NeedsDisambiguation(pos)
+	if file || rank {
+		t.Errorf("incorrect result: A3A2: expected false false, got %v %v", file, rank)
+	}
+	file, rank = Move{H1, B1, NoPieceType}.NeedsDisambiguation(pos)
+	if file || rank {
+		t.Errorf("incorrect result: H1B1: expected false false, got %v %v", file, rank)
+	}
+
+	pos, _ = ParseFen("k7/8/8/3R4/8/8/8/K2R3R w - - 0 1")
+	file, rank = Move{D1, D3, NoPieceType}.NeedsDisambiguation(pos)
+	if file || !rank {
+		t.Errorf("incorrect result: D1D3: expected false true, got %v %v", file, rank)
+	}
+
+	pos, _ = ParseFen("k7/8/8/8/8/8/8/R2K3R w - - 0 1")
+	file, rank = Move{H1, E1, NoPieceType}.NeedsDisambiguation(pos)
+	if file || rank {
+		t.Errorf("incorrect result: H1E1 is blocked for the other rook: expected false false, got %v %v", file, rank)
+	}
+
+	pos, _ = ParseFen("k7/8/8/8/8/8/8/1K1N1N2 w - - 0 1")
+	file, rank = Move{D1, E3, NoPieceType}.NeedsDisambiguation(pos)
+	if !file || rank {
+		t.Errorf("incorrect result: D1E3: expected true false, got %v %v", file, rank)
+	}
+
+	pos, _ = ParseFen("k3r3/8/8/8/8/8/4N3/2N1K3 w - - 0 1")
+	file, rank = Move{C1, D3, NoPieceType}.NeedsDisambiguation(pos)
+	if file || rank {
+		t.Errorf("incorrect result: pinned knight should not count: expected false false, got %v %v", file, rank)
+	}
+}
+
+func TestSanStringDisambiguationRankOnly(t *testing.T) {
+	pos, _ := ParseFen("7k/8/8/R7/8/2R5/8/R5K1 w - - 0 1")
+	move := Move{A1, A3, NoPieceType}
+	if san := move.SanString(pos); san != "R1a3" {
+		t.Errorf("incorrect result: expected R1a3, got %s", san)
+	}
+}
+
+func TestVariationSAN(t *testing.T) {
+	pos := getDefaultPosition()
+	moves := []Move{{E2, E4, NoPieceType}, {E7, E5, NoPieceType}, {F2, F4, NoPieceType}, {E5, F4, NoPieceType}}
+	sanMoves, err := VariationSAN(pos, moves)
+	if err != nil {
+		t.Fatalf("VariationSAN returned error: %v", err)
+	}
+	expected := []string{"e4", "e5", "f4", "exf4"}
+	if !slices.Equal(sanMoves, expected) {
+		t.Errorf("incorrect result: expected %v, got %v", expected, sanMoves)
+	}
+	if *pos != *getDefaultPosition() {
+		t.Error("VariationSAN modified the start position")
+	}
+
+	_, err = VariationSAN(pos, []Move{{E2, E4, NoPieceType}, {E2, E4, NoPieceType}})
+	if err == nil {
+		t.Error("incorrect result: illegal second move: expected error, got nil")
+	}
+}
+
+func TestMoveBetween(t *testing.T) {
+	before, _ := ParseFen("r3k2r/8/8/3pP3/8/8/8/R3K2R w KQkq d6 0 1")
+	tests := map[string]Move{
+		"r3k2r/8/3P4/8/8/8/8/R3K2R b KQkq - 0 1":   {E5, D6, NoPieceType},
+		"r3k2r/8/8/3pP3/8/8/8/R4RK1 b kq - 1 1":    {E1, G1, NoPieceType},
+		"r3k2r/8/8/3pP3/8/8/8/2KR3R b kq - 1 1":    {E1, C1, NoPieceType},
+		"r3k2r/8/4P3/3p4/8/8/8/R3K2R b KQkq - 0 1": {E5, E6, NoPieceType},
+	}
+	for fen, expected := range tests {
+		after, _ := ParseFen(fen)
+		move, err := MoveBetween(before, after)
+		if err != nil || move != expected {
+			t.Errorf("incorrect result: input %s: expected %v, got %v, %v", fen, expected, move, err)
+		}
+	}
+
+	before, _ = ParseFen("7k/P7/8/8/8/8/8/K7 w - - 0 1")
+	after, _ := ParseFen("R6k/8/8/8/8/8/8/K7 b - - 0 1")
+	move, err := MoveBetween(before, after)
+	if err != nil || move != (Move{A7, A8, Rook}) {
+		t.Errorf("incorrect result: promotion: expected A7A8R, got %v, %v", move, err)
+	}
+
+	_, err = MoveBetween(before, before)
+	if err == nil {
+		t.Error("incorrect result: same position: expected error, got nil")
+	}
+}
+
+func TestSanStringEnPassant(t *testing.T) {
+	pos, _ := ParseFen("4k3/8/8/3pP3/8/8/8/4RK2 w - d6 0 1")
+	move := Move{E5, D6, NoPieceType}
+	if san := move.SanString(pos); san != "exd6+" {
+		t.Errorf("incorrect result: discovered check: expected exd6+, got %s", san)
+	}
+
+	pos, _ = ParseFen("8/2k5/8/3pP3/8/8/8/5K2 w - d6 0 1")
+	if san := move.SanString(pos); san != "exd6+" {
+		t.Errorf("incorrect result: direct check: expected exd6+, got %s", san)
+	}
+
+	pos, _ = ParseFen("8/8/8/8/4pP2/8/8/4K1k1 b - f3 0 1")
+	move = Move{E4, F3, NoPieceType}
+	if san := move.SanString(pos); san != "exf3" {
+		t.Errorf("incorrect result: black en passant: expected exf3, got %s", san)
+	}
+	parsed, err := ParseSANMove(pos, "exf3")
+	if err != nil || parsed != move {
+		t.Errorf("incorrect result: parsing exf3: expected %v, got %v, %v", move, parsed, err)
+	}
+}
+
+func TestSANPromotionWithCheck(t *testing.T) {
+	pos, _ := ParseFen("5r2/6Pk/8/8/8/8/8/K7 w - - 0 1")
+	move, err := ParseSANMove(pos, "gxf8=N+")
+	if err != nil || move != (Move{G7, F8, Knight}) {
+		t.Errorf("incorrect result: input gxf8=N+: expected %v, got %v, %v", Move{G7, F8, Knight}, move, err)
+	}
+	if san := (Move{G7, F8, Knight}).SanString(pos); san != "gxf8=N+" {
+		t.Errorf("incorrect result: input g7f8n: expected gxf8=N+, got %s", san)
+	}
+
+	pos, _ = ParseFen("k7/8/8/8/8/8/4p1PP/7K b - - 0 1")
+	move, err = ParseSANMove(pos, "e1=Q#")
+	if err != nil || move != (Move{E2, E1, Queen}) {
+		t.Errorf("incorrect result: input e1=Q#: expected %v, got %v, %v", Move{E2, E1, Queen}, move, err)
+	}
+	if san := (Move{E2, E1, Queen}).SanString(pos); san != "e1=Q#" {
+		t.Errorf("incorrect result: input e2e1q: expected e1=Q#, got %s", san)
+	}
+
+	if _, err := ParseSANMove(pos, "e1=QR"); err == nil {
+		t.Error("incorrect result: input e1=QR: expected error, got nil")
+	}
+}
+
+func TestSanStringInvalidMove(t *testing.T) {
+	pos := getDefaultPosition()
+	if san := (Move{E7, E5, NoPieceType}).SanString(pos); san != "e7e5" {
+		t.Errorf("incorrect result: input e7e5: expected e7e5, got %s", san)
+	}
+	if san := (Move{}).SanString(pos); san != "0000" {
+		t.Errorf("incorrect result: input 0000: expected 0000, got %s", san)
+	}
+}
+
+func TestAppendSan(t *testing.T) {
+	pos := getDefaultPosition()
+	buf := []byte("1. ")
+	buf, err := (Move{G1, F3, NoPieceType}).AppendSan(buf, pos)
+	if err != nil || string(buf) != "1. Nf3" {
+		t.Errorf("incorrect result: input G1F3: expected 1. Nf3, got %s, %v", buf, err)
+	}
+	buf, err = (Move{E7, E5, NoPieceType}).AppendSan(buf, pos)
+	if err == nil || string(buf) != "1. Nf3" {
+		t.Errorf("incorrect result: input E7E5 with white to move: expected error and unchanged buffer, got %s, %v", buf, err)
+	}
+	allocs := testing.AllocsPerRun(100, func() {
+		buf, _ = (Move{E2, E4, NoPieceType}).AppendSan(buf[:0], pos)
+	})
+	if allocs != 0 {
+		t.Errorf("incorrect result: expected 0 allocations, got %v", allocs)
+	}
+}
+
+func TestParseSANMoveRankDisambiguationDiagonal(t *testing.T) {
+	pos, _ := ParseFen("k7/8/8/8/2B5/8/2B5/K7 w - - 0 1")
+	testCases := map[string]Move{
+		"B4b3": {C4, B3, NoPieceType},
+		"B2b3": {C2, B3, NoPieceType},
+		"B4d3": {C4, D3, NoPieceType},
+		"B2d3": {C2, D3, NoPieceType},
+	}
+	for moveString, expectedMove := range testCases {
+		move, err := ParseSANMove(pos, moveString)
+		if err != nil || move != expectedMove {
+			t.Errorf("incorrect result: input %s: expected %v, got %v, %v", moveString, expectedMove, move, err)
+		}
+	}
+
+	pos, _ = ParseFen("k7/8/8/8/2Q5/8/2Q5/K7 w - - 0 1")
+	for moveString, expectedMove := range testCases {
+		moveString = "Q" + moveString[1:]
+		expectedMove.FromSquare = Square{FileC, expectedMove.FromSquare.Rank}
+		move, err := ParseSANMove(pos, moveString)
+		if err != nil || move != expectedMove {
+			t.Errorf("incorrect result: input %s: expected %v, got %v, %v", moveString, expectedMove, move, err)
+		}
+	}
+}
+
+func TestParseUCIMovePromotionCase(t *testing.T) {
+	move, err := ParseUCIMove("a7a8Q")
+	if err != nil || move != (Move{A7, A8, Queen}) {
+		t.Errorf("incorrect result: input a7a8Q: expected %v, got %v, %v", Move{A7, A8, Queen}, move, err)
+	}
+	move, err = ParseUCIMove("a7a8n")
+	if err != nil || move != (Move{A7, A8, Knight}) {
+		t.Errorf("incorrect result: input a7a8n: expected %v, got %v, %v", Move{A7, A8, Knight}, move, err)
+	}
+	for _, moveString := range []string{"a7a8k", "a7a8K", "a7a8p"} {
+		if _, err := ParseUCIMove(moveString); err == nil {
+			t.Errorf("incorrect result: input %s: expected error, got nil", moveString)
+		}
+	}
+}
+
+func TestParseSANMoveAmbiguousError(t *testing.T) {
+	pos, _ := ParseFen("4k3/8/8/8/8/5N2/8/1N2K3 w - - 0 1")
+	_, err := ParseSANMove(pos, "Nd2")
+	var ambiguousErr *AmbiguousSANError
+	if !errors.As(err, &ambiguousErr) {
+		t.Fatalf("incorrect result: input Nd2: expected AmbiguousSANError, got %v", err)
+	}
+	expected := []Move{{F3, D2, NoPieceType}, {B1, D2, NoPieceType}}
+	slices.SortFunc(ambiguousErr.Candidates, CompareMoves)
+	if !slices.Equal(ambiguousErr.Candidates, expected) {
+		t.Errorf("incorrect result: input Nd2: expected candidates %v, got %v", expected, ambiguousErr.Candidates)
+	}
+
+	pos, _ = ParseFen("4k3/8/8/8/8/5N2/8/rN2K3 w - - 0 1")
+	move, err := ParseSANMove(pos, "Nd2")
+	if err != nil || move != (Move{F3, D2, NoPieceType}) {
+		t.Errorf("incorrect result: input Nd2 with pinned knight: expected %v, got %v, %v", Move{F3, D2, NoPieceType}, move, err)
+	}
+}
@@ -1,29 +1,94 @@
 package chess
 
+import (
+	"cmp"
+	"slices"
+)
+
+// MoveGenOptions restricts which moves are generated by [GeneratePseudoLegalMovesOpts]. The zero value generates every
+// move.
+type MoveGenOptions struct {
+	// NoCastling skips castling moves.
+	NoCastling bool
+	// CapturesOnly generates only moves that capture a piece, including en passant.
+	CapturesOnly bool
+	// QuietsOnly generates only moves that don't capture a piece. Castling and non-capturing promotions are quiet.
+	QuietsOnly bool
+}
+
 // GeneratePseudoLegalMoves expects a valid position. Behavior is undefined for invalid positions. This is to improve
 // performance since move generation is a vital part to engine development.
 func GeneratePseudoLegalMoves(p *Position) []Move {
+	return GeneratePseudoLegalMovesOpts(p, MoveGenOptions{})
+}
+
+// GeneratePseudoLegalMovesForColor generates the pseudo legal moves color c could make in p, whether or not it is c's
+// turn. Like [GeneratePseudoLegalMoves], moves that leave c's king in check are not filtered out. If it isn't c's turn
+// the en passant square is ignored, since only the side to move can capture en passant. p is not modified.
+func GeneratePseudoLegalMovesForColor(p *Position, c Color) []Move {
+	tempPosition := *p
+	if tempPosition.Turn != c {
+		tempPosition.Turn = c
+		tempPosition.EnPassant = NoSquare
+	}
+	return GeneratePseudoLegalMoves(&tempPosition)
+}
+
+// GeneratePseudoLegalMovesOpts works like [GeneratePseudoLegalMoves], but only generates the moves allowed by opts.
+// Setting both CapturesOnly and QuietsOnly generates no moves.
+func GeneratePseudoLegalMovesOpts(p *Position, opts MoveGenOptions) []Move {
+	if opts.CapturesOnly && opts.QuietsOnly {
+		return []Move{}
+	}
 	pseudoLegalMoves := []Move{}
 	for index, piece := range p.Board {
 		if piece.Color == p.Turn {
-			switch piece.Type {
-			case Pawn:
-				pseudoLegalMoves = append(pseudoLegalMoves, generatePawnMoves(p, indexToSquare(index))...)
-			case Rook:
-				pseudoLegalMoves = append(pseudoLegalMoves, generateRookMoves(p, indexToSquare(index))...)
-			case Knight:
-				pseudoLegalMoves = append(pseudoLegalMoves, generateKnightMoves(p, indexToSquare(index))...)
-			case Bishop:
-				pseudoLegalMoves = append(pseudoLegalMoves, generateBishopMoves(p, indexToSquare(index))...)
-			case Queen:
-				pseudoLegalMoves = append(pseudoLegalMoves, generateQueenMoves(p, indexToSquare(index))...)
-			case King:
-				pseudoLegalMoves = append(pseudoLegalMoves, generateKingMoves(p, indexToSquare(index))...)
+			pseudoLegalMoves = append(pseudoLegalMoves, generatePieceMoves(p, indexToSquare(index))...)
+			if piece.Type == King && !opts.NoCastling {
 				pseudoLegalMoves = append(pseudoLegalMoves, generateCastleMoves(p, indexToSquare(index))...)
 			}
 		}
 	}
-	return pseudoLegalMoves
+	if !opts.CapturesOnly && !opts.QuietsOnly {
+		return pseudoLegalMoves
+	}
+	filteredMoves := []Move{}
+	for _, move := range pseudoLegalMoves {
+		capture := isCapture(p, move)
+		if (opts.CapturesOnly && capture) || (opts.QuietsOnly && !capture) {
+			filteredMoves = append(filteredMoves, move)
+		}
+	}
+	return filteredMoves
+}
+
+// isCapture returns true if m captures a piece in p, including by en passant.
+func isCapture(p *Position, m Move) bool {
+	captured := p.PieceAt(m.ToSquare)
+	if captured != NoPiece {
+		return captured.Color != p.PieceAt(m.FromSquare).Color
+	}
+	return m.ToSquare == p.EnPassant && p.PieceAt(m.FromSquare).Type == Pawn && m.FromSquare.File != m.ToSquare.File
+}
+
+// generatePieceMoves generates the pseudo legal moves for the piece on s, excluding castling.
+func generatePieceMoves(p *Position, s Square) []Move {
+	switch p.PieceAt(s).Type {
+	case Pawn:
+		return generatePawnMoves(p, s)
+	case Rook:
+		return generateRookMoves(p, s)
+	case Knight:
+		return generateKnightMoves(p, s)
+	case Bishop:
+		return generateBishopMoves(p, s)
+	case Queen:
+		return generateQueenMoves(p, s)
+	case King:
+		return generateKingMoves(p, s)
+	default:
+		return []Move{}
+	}
 }
 
 func generatePawnMoves(p *Position, s Square) []Move {
@@ -308,19 +373,10 @@ func generateCastleMoves(p *Position, s Square) []Move {
 
 func generateWhiteCastleMoves(p *Position) []Move {
 	moves := []Move{}
-	if p.WhiteKingSideCastle &&
-		p.PieceAt(E1) == WhiteKing &&
-		p.PieceAt(F1) == NoPiece &&
-		p.PieceAt(G1) == NoPiece &&
-		p.PieceAt(H1) == WhiteRook {
+	if castleAvailable(p, White, true) {
 		moves = append(moves, Move{FromSquare: E1, ToSquare: G1, Promotion: NoPieceType})
 	}
-	if p.WhiteQueenSideCastle &&
-		p.PieceAt(E1) == WhiteKing &&
-		p.PieceAt(D1) == NoPiece &&
-		p.PieceAt(C1) == NoPiece &&
-		p.PieceAt(B1) == NoPiece &&
-		p.PieceAt(A1) == WhiteRook {
+	if castleAvailable(p, White, false) {
 		moves = append(moves, Move{FromSquare: E1, ToSquare: C1, Promotion: NoPieceType})
 	}
 	return moves
@@ -328,38 +384,213 @@ func generateWhiteCastleMoves(p *Position) []Move {
 
 func generateBlackCastleMoves(p *Position) []Move {
 	moves := []Move{}
-	if p.BlackKingSideCastle &&
-		p.PieceAt(E8) == BlackKing &&
-		p.PieceAt(F8) == NoPiece &&
-		p.PieceAt(G8) == NoPiece &&
-		p.PieceAt(H8) == BlackRook {
+	if castleAvailable(p, Black, true) {
 		moves = append(moves, Move{FromSquare: E8, ToSquare: G8, Promotion: NoPieceType})
 	}
-	if p.BlackQueenSideCastle &&
-		p.PieceAt(E8) == BlackKing &&
-		p.PieceAt(D8) == NoPiece &&
-		p.PieceAt(C8) == NoPiece &&
-		p.PieceAt(B8) == NoPiece &&
-		p.PieceAt(A8) == BlackRook {
+	if castleAvailable(p, Black, false) {
 		moves = append(moves, Move{FromSquare: E8, ToSquare: C8, Promotion: NoPieceType})
 	}
 	return moves
 }
 
+// CanCastle returns true if color c could castle kingside (or queenside if kingside is false) in p, ignoring whose turn
+// it is. c must have the castling right, the king and rook must be on their starting squares with nothing between
+// them, and the king must not be in check, pass through an attacked square, or end on an attacked square.
+func (p *Position) CanCastle(c Color, kingside bool) bool {
+	return castleAvailable(p, c, kingside) && castlePathSafe(p, c, kingside)
+}
+
+// castleAvailable returns true if c has the castling right, the king and rook are on their starting squares, and the
+// squares between them are empty. Whether the king is attacked is not checked, see castlePathSafe.
+func castleAvailable(p *Position, c Color, kingside bool) bool {
+	var hasRight bool
+	var rank Rank
+	switch c {
+	case White:
+		rank = Rank1
+		hasRight = (kingside && p.WhiteKingSideCastle) || (!kingside && p.WhiteQueenSideCastle)
+	case Black:
+		rank = Rank8
+		hasRight = (kingside && p.BlackKingSideCastle) || (!kingside && p.BlackQueenSideCastle)
+	default:
+		return false
+	}
+	kingSquare := Square{FileE, rank}
+	rookSquare := Square{FileA, rank}
+	if kingside {
+		rookSquare = Square{FileH, rank}
+	}
+	if !hasRight || p.PieceAt(kingSquare) != (Piece{c, King}) || p.PieceAt(rookSquare) != (Piece{c, Rook}) {
+		return false
+	}
+	for _, s := range SquaresBetween(kingSquare, rookSquare) {
+		if p.PieceAt(s) != NoPiece {
+			return false
+		}
+	}
+	return true
+}
+
+// castlePathSafe returns true if none of the squares c's king starts on, passes through, or ends on when castling are
+// attacked.
+func castlePathSafe(p *Position, c Color, kingside bool) bool {
+	rank := Rank1
+	if c == Black {
+		rank = Rank8
+	}
+	kingPath := []Square{{FileE, rank}, {FileD, rank}, {FileC, rank}}
+	if kingside {
+		kingPath = []Square{{FileE, rank}, {FileF, rank}, {FileG, rank}}
+	}
+	for _, s := range kingPath {
+		if isSquareAttacked(p, s, otherColor(c)) {
+			return false
+		}
+	}
+	return true
+}
+
 // GenerateLegalMoves expects a valid position. Behavior is undefined for invalid positions. This is to improve
 // performance since move generation is a vital part to engine development.
 func GenerateLegalMoves(p *Position) []Move {
-	pseudoLegalMoves := GeneratePseudoLegalMoves(p)
+	return FilterLegal(p, GeneratePseudoLegalMoves(p))
+}
+
+// GenerateLegalMovesSorted returns the same moves as [GenerateLegalMoves] in the order given by [CompareMoves]. The
+// order only depends on the moves themselves, so it is stable across versions of this package.
+func GenerateLegalMovesSorted(p *Position) []Move {
+	moves := GenerateLegalMoves(p)
+	slices.SortFunc(moves, CompareMoves)
+	return moves
+}
+
+// GenerateLegalPawnMoves generates only the legal moves of the side to move's pawns. Like [GenerateLegalMoves], it
+// expects a valid position.
+func GenerateLegalPawnMoves(p *Position) []Move {
+	return generateLegalMovesOfType(p, Pawn)
+}
+
+// GenerateLegalKnightMoves generates only the legal moves of the side to move's knights.
+func GenerateLegalKnightMoves(p *Position) []Move {
+	return generateLegalMovesOfType(p, Knight)
+}
+
+// GenerateLegalBishopMoves generates only the legal moves of the side to move's bishops.
+func GenerateLegalBishopMoves(p *Position) []Move {
+	return generateLegalMovesOfType(p, Bishop)
+}
+
+// GenerateLegalRookMoves generates only the legal moves of the side to move's rooks. Castling is a king move and is
+// not included.
+func GenerateLegalRookMoves(p *Position) []Move {
+	return generateLegalMovesOfType(p, Rook)
+}
+
+// GenerateLegalQueenMoves generates only the legal moves of the side to move's queens.
+func GenerateLegalQueenMoves(p *Position) []Move {
+	return generateLegalMovesOfType(p, Queen)
+}
+
+// GenerateLegalKingMoves generates only the legal moves of the side to move's king, including castling.
+func GenerateLegalKingMoves(p *Position) []Move {
+	return generateLegalMovesOfType(p, King)
+}
+
+func generateLegalMovesOfType(p *Position, pt PieceType) []Move {
+	pseudoLegalMoves := []Move{}
+	for index, piece := range p.Board {
+		if piece.Color != p.Turn || piece.Type != pt {
+			continue
+		}
+		pseudoLegalMoves = append(pseudoLegalMoves, generatePieceMoves(p, indexToSquare(index))...)
+		if pt == King {
+			pseudoLegalMoves = append(pseudoLegalMoves, generateCastleMoves(p, indexToSquare(index))...)
+		}
+	}
+	return FilterLegal(p, pseudoLegalMoves)
+}
+
+// CompareMoves orders moves by from square, then to square, then promotion, for use with [slices.SortFunc]. Squares are
+// ordered from A8 to H1 like [AllSquares], and promotions in the order of the [PieceType] constants.
+func CompareMoves(a Move, b Move) int {
+	if c := cmp.Compare(squareToIndex(a.FromSquare), squareToIndex(b.FromSquare)); c != 0 {
+		return c
+	}
+	if c := cmp.Compare(squareToIndex(a.ToSquare), squareToIndex(b.ToSquare)); c != 0 {
+		return c
+	}
+	return cmp.Compare(a.Promotion, b.Promotion)
+}
+
+// FilterLegal returns the moves in moves that don't leave the moving side's king in check, and that don't castle out of
+// or through check. moves are expected to be pseudo legal moves for p, such as those from [GeneratePseudoLegalMoves].
+// This allows custom move generators to reuse the legality check done by [GenerateLegalMoves].
+func FilterLegal(p *Position, moves []Move) []Move {
 	isCurrentPositionCheck := IsCheck(p)
 	legalMoves := []Move{}
-	for _, move := range pseudoLegalMoves {
-		var tempPosition Position = *p
-		tempPosition.Move(move)
-		tempPosition.Turn = p.Turn
-		castleMove := isCastleMove(p, move)
-		if !IsCheck(&tempPosition) && ((castleMove && !isCurrentPositionCheck) || !castleMove) {
+	for _, move := range moves {
+		if isPseudoLegalMoveLegal(p, move, isCurrentPositionCheck) {
 			legalMoves = append(legalMoves, move)
 		}
 	}
 	return legalMoves
 }
+
+// isPseudoLegalMoveLegal returns true if the pseudo legal move m doesn't leave the moving side's king in check, and
+// isn't a castle out of or through check. inCheck must be IsCheck(p).
+func isPseudoLegalMoveLegal(p *Position, m Move, inCheck bool) bool {
+	var tempPosition Position = *p
+	tempPosition.Move(m)
+	tempPosition.Turn = p.Turn
+	if isCastleMove(p, m) && (inCheck || !castlePathSafe(p, p.Turn, m.ToSquare.File == FileG)) {
+		return false
+	}
+	return !IsCheck(&tempPosition)
+}
+
+// HasLegalMove returns true if the side to move has at least one legal move. It stops at the first legal move found,
+// so it is much cheaper than checking the length of [GenerateLegalMoves].
+func (p *Position) HasLegalMove() bool {
+	inCheck := IsCheck(p)
+	for index, piece := range p.Board {
+		if piece.Color != p.Turn {
+			continue
+		}
+		moves := generatePieceMoves(p, indexToSquare(index))
+		if piece.Type == King {
+			moves = append(moves, generateCastleMoves(p, indexToSquare(index))...)
+		}
+		for _, move := range moves {
+			if isPseudoLegalMoveLegal(p, move, inCheck) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// IsQuiet returns true if none of the legal moves of the side to move capture a piece (including en passant), promote,
+// or give check. Positions with no legal moves are quiet.
+func (p *Position) IsQuiet() bool {
+	for _, move := range GenerateLegalMoves(p) {
+		if move.Promotion != NoPieceType || isCapture(p, move) || IsCheck(p.AfterMove(move)) {
+			return false
+		}
+	}
+	return true
+}
+
+// Mobility returns the number of pseudo-legal destination squares for each piece type of color c, regardless of whose
+// turn it is. The moves counted are those of [GeneratePseudoLegalMovesForColor], so en passant is only counted when c is
+// the side to move, and castling is counted as king mobility. Squares occupied by c's own pieces are never counted, and
+// a pawn promotion counts as a single destination no matter how many pieces it can promote to.
+func (p *Position) Mobility(c Color) map[PieceType]int {
+	mobility := map[PieceType]int{}
+	for _, move := range GeneratePseudoLegalMovesForColor(p, c) {
+		if move.Promotion != NoPieceType && move.Promotion != Queen {
+			continue
+		}
+		mobility[p.PieceAt(move.FromSquare).Type]++
+	}
+	return mobility
+}
@@ -19,22 +19,6 @@ func moveSetsEqual(m1 []Move, m2 []Move) bool {
 	return true
 }
 
-func moveSortFunc(a, b Move) int {
-	if squareToIndex(a.FromSquare) < squareToIndex(b.FromSquare) {
-		return -1
-	}
-	if squareToIndex(a.FromSquare) > squareToIndex(b.FromSquare) {
-		return 1
-	}
-	if squareToIndex(a.ToSquare) < squareToIndex(b.ToSquare) {
-		return -1
-	}
-	if squareToIndex(a.ToSquare) > squareToIndex(b.ToSquare) {
-		return 1
-	}
-	return 0
-}
-
 func TestGeneratePseudoLegalMoves(t *testing.T) {
 	defaultMoveSet := []Move{
 		{A2, A3, NoPieceType},
@@ -119,8 +103,8 @@ func TestGeneratePseudoLegalMoves(t *testing.T) {
 		{H1, F1, NoPieceType},
 	}
 	moves = GeneratePseudoLegalMoves(pos)
-	slices.SortFunc(expectedMoves, moveSortFunc)
-	slices.SortFunc(moves, moveSortFunc)
+	slices.SortFunc(expectedMoves, CompareMoves)
+	slices.SortFunc(moves, CompareMoves)
 	if !moveSetsEqual(expectedMoves, moves) {
 		t.Error("incorrect result: fen = r3kb1r/2p3pp/pp3n2/q4P2/2B1p3/6Q1/PPP2PPP/RNB1K2R w KQkq - 2 14 ", cmp.Diff(expectedMoves, moves))
 	}
@@ -169,8 +153,8 @@ func TestGeneratePseudoLegalMoves(t *testing.T) {
 		{E4, E3, NoPieceType},
 	}
 	moves = GeneratePseudoLegalMoves(pos)
-	slices.SortFunc(expectedMoves, moveSortFunc)
-	slices.SortFunc(moves, moveSortFunc)
+	slices.SortFunc(expectedMoves, CompareMoves)
+	slices.SortFunc(moves, CompareMoves)
 	if !moveSetsEqual(expectedMoves, moves) {
 		t.Error("incorrect result: fen = r3kb1r/2p3pp/pp3n2/q4P2/2B1p3/6Q1/PPP2PPP/RNB1K2R b KQkq - 2 14 ", cmp.Diff(expectedMoves, moves))
 	}
@@ -219,8 +203,8 @@ func TestGenerateLegalMoves(t *testing.T) {
 		{E1, F1, NoPieceType},
 	}
 	moves = GenerateLegalMoves(pos)
-	slices.SortFunc(expectedMoves, moveSortFunc)
-	slices.SortFunc(moves, moveSortFunc)
+	slices.SortFunc(expectedMoves, CompareMoves)
+	slices.SortFunc(moves, CompareMoves)
 	if !moveSetsEqual(expectedMoves, moves) {
 		t.Error("incorrect result: fen = r3kb1r/2p3pp/pp3n2/q4P2/2B1p3/6Q1/PPP2PPP/RNB1K2R w KQkq - 2 14 ", cmp.Diff(expectedMoves, moves))
 	}
@@ -268,8 +252,8 @@ func TestGenerateLegalMoves(t *testing.T) {
 		{E4, E3, NoPieceType},
 	}
 	moves = GenerateLegalMoves(pos)
-	slices.SortFunc(expectedMoves, moveSortFunc)
-	slices.SortFunc(moves, moveSortFunc)
+	slices.SortFunc(expectedMoves, CompareMoves)
+	slices.SortFunc(moves, CompareMoves)
 	if !moveSetsEqual(expectedMoves, moves) {
 		t.Error("incorrect result: fen = r3kb1r/2p3pp/pp3n2/q4P2/2B1p3/6Q1/PPP2PPP/RNB1K2R b KQkq - 2 14 ", cmp.Diff(expectedMoves, moves))
 	}
@@ -725,3 +709,240 @@ func BenchmarkGenerateLegalMoves(b *testing.B) {
 		GenerateLegalMoves(pos)
 	}
 }
+
+func TestGenerateLegalMovesByPieceType(t *testing.T) {
+	positions := []string{
+		"rnbqkbnr/pppppppp/8/8/8/8/PPPPPPPP/RNBQKBNR w KQkq - 0 1",
+		"r3kb1r/2p3pp/pp3n2/q4P2/2B1p3/6Q1/PPP2PPP/RNB1K2R w KQkq - 2 14",
+		"r3k2r/8/8/8/8/8/5r2/R3K2R w KQkq - 0 1",
+		"4k3/8/8/3pP3/8/8/8/4K3 w - d6 0 1",
+	}
+	generators := map[PieceType]func(*Position) []Move{
+		Pawn:   GenerateLegalPawnMoves,
+		Knight: GenerateLegalKnightMoves,
+		Bishop: GenerateLegalBishopMoves,
+		Rook:   GenerateLegalRookMoves,
+		Queen:  GenerateLegalQueenMoves,
+		King:   GenerateLegalKingMoves,
+	}
+	for _, fen := range positions {
+		pos, _ := ParseFen(fen)
+		all := GenerateLegalMoves(pos)
+		for pieceType, generate := range generators {
+			expected := []Move{}
+			for _, move := range all {
+				if pos.PieceAt(move.FromSquare).Type == pieceType {
+					expected = append(expected, move)
+				}
+			}
+			if moves := generate(pos); !moveSetsEqual(moves, expected) {
+				t.Errorf("incorrect result: input %s, %v moves: expected %v, got %v", fen, pieceType, expected, moves)
+			}
+		}
+	}
+}
+
+func BenchmarkGenerateLegalKnightMoves(b *testing.B) {
+	pos, _ := ParseFen("r3kb1r/2p3pp/pp3n2/q4P2/2B1p3/6Q1/PPP2PPP/RNB1K2R w KQkq - 2 14")
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		GenerateLegalKnightMoves(pos)
+	}
+}
+
+func BenchmarkGenerateLegalKingMoves(b *testing.B) {
+	pos, _ := ParseFen("r3kb1r/2p3pp/pp3n2/q4P2/2B1p3/6Q1/PPP2PPP/RNB1K2R w KQkq - 2 14")
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		GenerateLegalKingMoves(pos)
+	}
+}
+
+func TestMobility(t *testing.T) {
+	position := getDefaultPosition()
+	mobility := position.Mobility(White)
+	if mobility[Knight] != 4 {
+		t.Errorf("incorrect result: knight mobility: expected 4, got %d", mobility[Knight])
+	}
+	if mobility[Pawn] != 16 {
+		t.Errorf("incorrect result: pawn mobility: expected 16, got %d", mobility[Pawn])
+	}
+	if mobility[Bishop] != 0 || mobility[King] != 0 {
+		t.Errorf("incorrect result: expected no bishop or king mobility, got %v", mobility)
+	}
+	if black := position.Mobility(Black); black[Knight] != 4 {
+		t.Errorf("incorrect result: black knight mobility: expected 4, got %d", black[Knight])
+	}
+
+	position, _ = ParseFen("1n5k/P7/8/8/8/8/8/K7 w - - 0 1")
+	mobility = position.Mobility(White)
+	if mobility[Pawn] != 2 {
+		t.Errorf("incorrect result: promoting pawn mobility: expected 2, got %d", mobility[Pawn])
+	}
+
+	position, _ = ParseFen("4k3/8/8/3pP3/8/8/8/4K3 b - d6 0 1")
+	if mobility = position.Mobility(White); mobility[Pawn] != 1 {
+		t.Errorf("incorrect result: en passant when not to move: expected 1 pawn move, got %d", mobility[Pawn])
+	}
+
+	position, _ = ParseFen("4k3/8/8/8/8/8/8/4K2R w K - 0 1")
+	if mobility = position.Mobility(White); mobility[King] != 6 {
+		t.Errorf("incorrect result: castling should count as king mobility: expected 6, got %d", mobility[King])
+	}
+}
+
+func TestFilterLegal(t *testing.T) {
+	pos, _ := ParseFen("4r1k1/8/8/8/8/8/4N3/4K3 w - - 0 1")
+	knightMoves := generateKnightMoves(pos, E2)
+	if len(knightMoves) == 0 {
+		t.Fatal("expected pseudo legal knight moves")
+	}
+	if legal := FilterLegal(pos, knightMoves); len(legal) != 0 {
+		t.Errorf("incorrect result: pinned knight: expected no legal moves, got %v", legal)
+	}
+	kingMoves := generateKingMoves(pos, E1)
+	expected := []Move{{E1, F2, NoPieceType}, {E1, F1, NoPieceType}, {E1, D1, NoPieceType}, {E1, D2, NoPieceType}}
+	if legal := FilterLegal(pos, kingMoves); !moveSetsEqual(legal, expected) {
+		t.Errorf("incorrect result: king moves: expected %v, got %v", expected, legal)
+	}
+}
+
+func TestGeneratePseudoLegalMovesOpts(t *testing.T) {
+	pos, _ := ParseFen("4k3/8/8/3pP3/8/8/8/R3K2r w Q d6 0 1")
+	captures := GeneratePseudoLegalMovesOpts(pos, MoveGenOptions{CapturesOnly: true})
+	expected := []Move{{E5, D6, NoPieceType}}
+	if !moveSetsEqual(captures, expected) {
+		t.Errorf("incorrect result: captures only: expected %v, got %v", expected, captures)
+	}
+
+	all := GeneratePseudoLegalMoves(pos)
+	quiets := GeneratePseudoLegalMovesOpts(pos, MoveGenOptions{QuietsOnly: true})
+	if len(quiets)+len(captures) != len(all) {
+		t.Errorf("incorrect result: captures and quiets should partition all moves: %d + %d != %d", len(captures), len(quiets), len(all))
+	}
+	if !slices.Contains(quiets, Move{E1, C1, NoPieceType}) {
+		t.Error("incorrect result: castling should be a quiet move")
+	}
+
+	noCastling := GeneratePseudoLegalMovesOpts(pos, MoveGenOptions{NoCastling: true})
+	if slices.Contains(noCastling, Move{E1, C1, NoPieceType}) || len(noCastling) != len(all)-1 {
+		t.Errorf("incorrect result: no castling: expected %d moves without castling, got %v", len(all)-1, noCastling)
+	}
+
+	if moves := GeneratePseudoLegalMovesOpts(pos, MoveGenOptions{CapturesOnly: true, QuietsOnly: true}); len(moves) != 0 {
+		t.Errorf("incorrect result: captures and quiets only: expected no moves, got %v", moves)
+	}
+}
+
+func TestHasLegalMove(t *testing.T) {
+	testCases := []struct {
+		fen      string
+		expected bool
+	}{
+		{"rnbqkbnr/pppppppp/8/8/8/8/PPPPPPPP/RNBQKBNR w KQkq - 0 1", true},
+		{"rnb1kbnr/pppp1ppp/8/4p3/6Pq/5P2/PPPPP2P/RNBQKBNR w KQkq - 1 3", false},
+		{"7k/5Q2/6K1/8/8/8/8/8 b - - 0 1", false},
+		{"7k/8/4Q3/8/8/8/8/6K1 b - - 0 1", true},
+	}
+	for _, tc := range testCases {
+		pos, _ := ParseFen(tc.fen)
+		if pos.HasLegalMove() != tc.expected {
+			t.Errorf("incorrect result: input %s: expected %v, got %v", tc.fen, tc.expected, !tc.expected)
+		}
+		if pos.HasLegalMove() != (len(GenerateLegalMoves(pos)) > 0) {
+			t.Errorf("incorrect result: input %s: disagrees with GenerateLegalMoves", tc.fen)
+		}
+	}
+}
+
+func TestGenerateLegalMovesSorted(t *testing.T) {
+	pos, _ := ParseFen("4k3/1P6/8/8/8/8/8/4K3 w - - 0 1")
+	moves := GenerateLegalMovesSorted(pos)
+	expected := []Move{
+		{B7, B8, Rook}, {B7, B8, Knight}, {B7, B8, Bishop}, {B7, B8, Queen},
+		{E1, D2, NoPieceType}, {E1, E2, NoPieceType}, {E1, F2, NoPieceType}, {E1, D1, NoPieceType}, {E1, F1, NoPieceType},
+	}
+	if !slices.Equal(moves, expected) {
+		t.Errorf("incorrect result: expected %v, got %v", expected, moves)
+	}
+}
+
+func TestGeneratePseudoLegalMovesForColor(t *testing.T) {
+	pos, _ := ParseFen("rnbqkbnr/ppp1p1pp/8/3pPp2/8/8/PPPP1PPP/RNBQKBNR w KQkq f6 0 3")
+	moves := GeneratePseudoLegalMovesForColor(pos, Black)
+	if !slices.Contains(moves, Move{D8, D6, NoPieceType}) || slices.Contains(moves, Move{E5, F6, NoPieceType}) {
+		t.Errorf("incorrect result: Black: unexpected moves %v", moves)
+	}
+	if pos.Turn != White {
+		t.Error("incorrect result: position was modified")
+	}
+	moves = GeneratePseudoLegalMovesForColor(pos, White)
+	if !slices.Equal(moves, GeneratePseudoLegalMoves(pos)) {
+		t.Errorf("incorrect result: White: expected same moves as GeneratePseudoLegalMoves, got %v", moves)
+	}
+
+	pos, _ = ParseFen("rnbqkbnr/pppp1ppp/8/4p3/4P3/8/PPPP1PPP/RNBQKBNR b KQkq e3 0 2")
+	moves = GeneratePseudoLegalMovesForColor(pos, White)
+	if !slices.Contains(moves, Move{G1, F3, NoPieceType}) {
+		t.Errorf("incorrect result: White not to move: expected Nf3 in %v", moves)
+	}
+}
+
+func TestIsQuiet(t *testing.T) {
+	testCases := []struct {
+		fen      string
+		expected bool
+	}{
+		{"rnbqkbnr/pppppppp/8/8/8/8/PPPPPPPP/RNBQKBNR w KQkq - 0 1", true},
+		{"rnbqkbnr/ppp1pppp/8/3p4/4P3/8/PPPP1PPP/RNBQKBNR w KQkq - 0 2", false},
+		{"4k3/1P6/8/8/8/8/8/K7 w - - 0 1", false},
+		{"7k/8/8/8/8/8/8/K5R1 w - - 0 1", false},
+		{"7k/8/8/8/8/8/8/K7 w - - 0 1", true},
+	}
+	for _, tc := range testCases {
+		pos, _ := ParseFen(tc.fen)
+		if pos.IsQuiet() != tc.expected {
+			t.Errorf("incorrect result: input %s: expected %v, got %v", tc.fen, tc.expected, !tc.expected)
+		}
+	}
+}
+
+func TestCanCastle(t *testing.T) {
+	testCases := []struct {
+		fen      string
+		color    Color
+		kingside bool
+		expected bool
+	}{
+		{"r3k2r/8/8/8/8/8/8/R3K2R w KQkq - 0 1", White, true, true},
+		{"r3k2r/8/8/8/8/8/8/R3K2R w KQkq - 0 1", Black, false, true},
+		{"r3k2r/8/8/8/8/8/8/R3K2R w Qkq - 0 1", White, true, false},
+		{"r3k2r/8/8/8/8/8/8/RN2K2R w KQkq - 0 1", White, false, false},
+		{"r3k2r/8/8/8/8/8/8/R3K1NR w KQkq - 0 1", White, true, false},
+		{"r3k2r/8/8/8/8/8/8/R3K2R w KQkq - 0 1", White, false, true},
+		{"r3k2r/8/8/8/8/8/4r3/R3K2R w KQkq - 0 1", White, true, false},
+		{"r3k2r/8/8/8/8/8/5r2/R3K2R w KQkq - 0 1", White, true, false},
+		{"r3k2r/8/8/8/8/8/6r1/R3K2R w KQkq - 0 1", White, true, false},
+		{"r3k2r/8/8/8/8/8/7r/R3K2R w KQkq - 0 1", White, true, true},
+		{"r3k2r/8/8/8/8/8/1r6/R3K2R w KQkq - 0 1", White, false, true},
+		{"r3k2r/8/8/8/8/8/8/R3K2R w - - 0 1", Black, true, false},
+		{"r3k2r/8/8/8/8/8/8/R3K2R b KQkq - 0 1", White, true, true},
+	}
+	for _, tc := range testCases {
+		pos, _ := ParseFen(tc.fen)
+		if got := pos.CanCastle(tc.color, tc.kingside); got != tc.expected {
+			t.Errorf("incorrect result: input %s, %v, kingside %v: expected %v, got %v", tc.fen, tc.color, tc.kingside, tc.expected, got)
+		}
+	}
+}
+
+func TestGenerateLegalMovesCastleThroughCheck(t *testing.T) {
+	pos, _ := ParseFen("r3k2r/8/8/8/8/8/5r2/R3K2R w KQkq - 0 1")
+	moves := GenerateLegalMoves(pos)
+	if slices.Contains(moves, Move{E1, G1, NoPieceType}) {
+		t.Error("incorrect result: castled through an attacked square")
+	}
+	if !slices.Contains(moves, Move{E1, C1, NoPieceType}) {
+		t.Error("incorrect result: queenside castle should be legal")
+	}
+}
@@ -2,6 +2,7 @@ package chess
 
 import (
 	"errors"
+	"fmt"
 	"strings"
 	"unicode"
 )
@@ -40,6 +41,36 @@ func (pt PieceType) String() string {
 	}
 }
 
+// MarshalText implements [encoding.TextMarshaler]. It uses the same letters as [PieceType.String], with "-" for
+// [NoPieceType].
+func (pt PieceType) MarshalText() ([]byte, error) {
+	if !isValidPieceType(pt) {
+		return nil, fmt.Errorf("can't marshal invalid piece type %d", pt)
+	}
+	if pt == NoPieceType {
+		return []byte("-"), nil
+	}
+	return []byte(pt.String()), nil
+}
+
+// UnmarshalText implements [encoding.TextUnmarshaler]. It accepts the output of [PieceType.MarshalText] in either case.
+func (pt *PieceType) UnmarshalText(text []byte) error {
+	runes := []rune(string(text))
+	if len(runes) != 1 {
+		return fmt.Errorf("can't unmarshal piece type: %q", text)
+	}
+	if runes[0] == '-' {
+		*pt = NoPieceType
+		return nil
+	}
+	parsed, err := parsePieceType(runes[0])
+	if err != nil {
+		return fmt.Errorf("can't unmarshal piece type: %q", text)
+	}
+	*pt = parsed
+	return nil
+}
+
 func isValidPieceType(pt PieceType) bool {
 	return pt <= 6
 }
@@ -102,6 +133,35 @@ func (p Piece) String() string {
 	return pieceStr
 }
 
+// MarshalText implements [encoding.TextMarshaler]. It uses the same letters as [Piece.String], with "." for [NoPiece].
+func (p Piece) MarshalText() ([]byte, error) {
+	if !isValidPiece(p) {
+		return nil, errors.New("can't marshal invalid piece")
+	}
+	if p == NoPiece {
+		return []byte("."), nil
+	}
+	return []byte(p.String()), nil
+}
+
+// UnmarshalText implements [encoding.TextUnmarshaler]. It accepts the output of [Piece.MarshalText].
+func (p *Piece) UnmarshalText(text []byte) error {
+	runes := []rune(string(text))
+	if len(runes) != 1 {
+		return fmt.Errorf("can't unmarshal piece: %q", text)
+	}
+	if runes[0] == '.' {
+		*p = NoPiece
+		return nil
+	}
+	parsed, err := ParsePiece(runes[0])
+	if err != nil {
+		return fmt.Errorf("can't unmarshal piece: %q", text)
+	}
+	*p = parsed
+	return nil
+}
+
 func isValidPiece(p Piece) bool {
 	if !isValidPieceType(p.Type) || !isValidColor(p.Color) {
 		return false
@@ -1,6 +1,8 @@
 package chess
 
 import (
+	"encoding/json"
+	"slices"
 	"testing"
 )
 
@@ -44,3 +46,66 @@ func TestPieceToString(t *testing.T) {
 		t.Error("Black bishop does not equal \"b\"")
 	}
 }
+
+func TestPieceMarshalText(t *testing.T) {
+	text, err := BlackKnight.MarshalText()
+	if err != nil || string(text) != "n" {
+		t.Errorf("incorrect result: input BlackKnight: expected n, got %s, %v", text, err)
+	}
+	text, err = NoPiece.MarshalText()
+	if err != nil || string(text) != "." {
+		t.Errorf("incorrect result: input NoPiece: expected ., got %s, %v", text, err)
+	}
+	_, err = Piece{White, NoPieceType}.MarshalText()
+	if err == nil {
+		t.Error("incorrect result: input invalid piece: expected error, got nil")
+	}
+}
+
+func TestPieceUnmarshalText(t *testing.T) {
+	var piece Piece
+	if err := piece.UnmarshalText([]byte("N")); err != nil || piece != WhiteKnight {
+		t.Errorf("incorrect result: input N: expected %v, got %v, %v", WhiteKnight, piece, err)
+	}
+	if err := piece.UnmarshalText([]byte(".")); err != nil || piece != NoPiece {
+		t.Errorf("incorrect result: input .: expected NoPiece, got %v, %v", piece, err)
+	}
+	if err := piece.UnmarshalText([]byte("x")); err == nil {
+		t.Error("incorrect result: input x: expected error, got nil")
+	}
+}
+
+func TestPieceJSONRoundTrip(t *testing.T) {
+	board := []Piece{WhiteKing, BlackPawn, NoPiece}
+	data, err := json.Marshal(board)
+	if err != nil {
+		t.Fatalf("failed to marshal pieces: %v", err)
+	}
+	if string(data) != `["K","p","."]` {
+		t.Errorf("incorrect result: expected [\"K\",\"p\",\".\"], got %s", data)
+	}
+	var parsed []Piece
+	if err := json.Unmarshal(data, &parsed); err != nil {
+		t.Fatalf("failed to unmarshal pieces: %v", err)
+	}
+	if !slices.Equal(board, parsed) {
+		t.Errorf("incorrect result: expected %v, got %v", board, parsed)
+	}
+}
+
+func TestPieceTypeMarshalText(t *testing.T) {
+	text, err := Queen.MarshalText()
+	if err != nil || string(text) != "Q" {
+		t.Errorf("incorrect result: input Queen: expected Q, got %s, %v", text, err)
+	}
+	var pt PieceType
+	if err := pt.UnmarshalText([]byte("q")); err != nil || pt != Queen {
+		t.Errorf("incorrect result: input q: expected Queen, got %v, %v", pt, err)
+	}
+	if err := pt.UnmarshalText([]byte("-")); err != nil || pt != NoPieceType {
+		t.Errorf("incorrect result: input -: expected NoPieceType, got %v, %v", pt, err)
+	}
+	if _, err := PieceType(9).MarshalText(); err == nil {
+		t.Error("incorrect result: input 9: expected error, got nil")
+	}
+}
@@ -3,6 +3,7 @@ package chess
 import (
 	"errors"
 	"fmt"
+	"slices"
 	"strconv"
 	"strings"
 	"unicode"
@@ -67,6 +68,73 @@ func (p *Position) FormatString(blacksPerspective bool) string {
 	}
 }
 
+// FormatStringANSI returns a representation of the board for terminals, using ANSI escape codes for colored squares and
+// Unicode chess pieces. Both the square and piece colors are set explicitly, so the board looks the same on light and
+// dark terminal themes. blacksPerspective should be true to print the board from black's side. Use
+// [Position.FormatString] for output that isn't going to a terminal.
+func (p *Position) FormatStringANSI(blacksPerspective bool) string {
+	ranks := []Rank{Rank8, Rank7, Rank6, Rank5, Rank4, Rank3, Rank2, Rank1}
+	files := []File{FileA, FileB, FileC, FileD, FileE, FileF, FileG, FileH}
+	if blacksPerspective {
+		slices.Reverse(ranks)
+		slices.Reverse(files)
+	}
+	str := strings.Builder{}
+	for _, rank := range ranks {
+		str.WriteString(rank.String() + " ")
+		for _, file := range files {
+			square := Square{file, rank}
+			if square.IsLight() {
+				str.WriteString(ansiLightSquare)
+			} else {
+				str.WriteString(ansiDarkSquare)
+			}
+			piece := p.PieceAt(square)
+			if piece.Color == White {
+				str.WriteString(ansiWhitePiece)
+			} else {
+				str.WriteString(ansiBlackPiece)
+			}
+			str.WriteString(" " + pieceGlyph(piece) + " ")
+		}
+		str.WriteString(ansiReset + "\n")
+	}
+	str.WriteString("  ")
+	for _, file := range files {
+		str.WriteString(" " + strings.ToLower(file.String()) + " ")
+	}
+	return str.String()
+}
+
+const (
+	ansiLightSquare = "\x1b[48;5;180m"
+	ansiDarkSquare  = "\x1b[48;5;137m"
+	ansiWhitePiece  = "\x1b[38;5;231m"
+	ansiBlackPiece  = "\x1b[38;5;16m"
+	ansiReset       = "\x1b[0m"
+)
+
+// pieceGlyph returns the solid Unicode chess symbol for piece's type, or a space for [NoPiece]. The solid symbols are
+// used for both colors so that the piece color can be set by the terminal foreground color.
+func pieceGlyph(piece Piece) string {
+	switch piece.Type {
+	case Pawn:
+		return "♟"
+	case Rook:
+		return "♜"
+	case Knight:
+		return "♞"
+	case Bishop:
+		return "♝"
+	case Queen:
+		return "♛"
+	case King:
+		return "♚"
+	default:
+		return " "
+	}
+}
+
 func (p *Position) PieceAt(s Square) Piece {
 	if !isValidSquare(s) || s == NoSquare {
 		return NoPiece
@@ -94,6 +162,14 @@ func (p *Position) Move(m Move) {
 	p.updateEnPassant(m)
 }
 
+// AfterMove returns a copy of p with m applied, leaving p unchanged. Like [Position.Move] it does no checking of move
+// legality.
+func (p *Position) AfterMove(m Move) *Position {
+	newPosition := *p
+	newPosition.Move(m)
+	return &newPosition
+}
+
 func (p *Position) updateMoveCounts(m Move) {
 	if p.PieceAt(m.FromSquare).Type == Pawn || p.PieceAt(m.ToSquare) != NoPiece || isCastleMove(p, m) {
 		p.HalfMove = 0
@@ -212,6 +288,7 @@ func indexToSquare(index int) Square {
 }
 
 // ParseFen take only fully formed valid FEN strings. All parts of the FEN must be present, though the position need not necessarily be valid.
+// A castling right that is listed more than once, such as "KKq", is treated as if it was listed once.
 func ParseFen(fen string) (*Position, error) {
 	words := strings.Split(fen, " ")
 	if len(words) != 6 {
@@ -315,6 +392,13 @@ func parseCastleRights(castleRights string) ([4]bool, error) {
 	return rights, nil
 }
 
+// ParsePositionLenient parses fen like [ParseFen], for FENs from third party tools that list castling rights more than
+// once, such as "KKqq". Repeated rights are treated as if each was listed once, which is also what [ParseFen] does, so
+// the two only differ in name; use this one where accepting such FENs is intended.
+func ParsePositionLenient(fen string) (*Position, error) {
+	return ParseFen(fen)
+}
+
 func GenerateFen(p *Position) string {
 	fen := strings.Builder{}
 	fen.WriteString(generateFenPos(p))
@@ -326,6 +410,81 @@ func GenerateFen(p *Position) string {
 	return fen.String()
 }
 
+// Key returns a string that uniquely identifies the position ignoring move counters. It is made up of the first four
+// fields of the FEN, except that the en passant square is only included if an en passant capture is actually legal.
+// This makes it suitable as a map key for detecting repeated positions.
+func (p *Position) Key() string {
+	key := strings.Builder{}
+	key.WriteString(generateFenPos(p))
+	key.WriteString(" " + generateFenTurn(p))
+	key.WriteString(" " + generateFenCastleRights(p))
+	key.WriteString(" " + strings.ToLower(legalEnPassantSquare(p).String()))
+	return key.String()
+}
+
+// EqualityFEN returns the same string as [Position.Key]: the board, side to move and castling fields of the FEN,
+// followed by the en passant square only if an en passant capture is legal. Two positions that are equal ignoring move
+// counters always produce the same string, and positions that differ otherwise never do.
+func (p *Position) EqualityFEN() string {
+	return p.Key()
+}
+
+// CanonicalKey returns the same key for a position and its mirror image across the d/e file boundary, so that
+// positions which only differ by which side of the board they are on can be treated as equivalent. Mirroring is only
+// applied when neither side has any castling rights, since castling is not symmetric. In that case the lexicographically
+// smaller of the position's [Position.Key] and its mirror's is returned. Otherwise the Key is returned unchanged.
+func (p *Position) CanonicalKey() string {
+	key := p.Key()
+	if p.WhiteKingSideCastle || p.WhiteQueenSideCastle || p.BlackKingSideCastle || p.BlackQueenSideCastle {
+		return key
+	}
+	return min(key, mirrorFiles(p).Key())
+}
+
+// mirrorFiles returns a copy of p with every piece and the en passant square moved to the opposite file (a to h, b to
+// g, etc.). Castling rights are copied unchanged.
+func mirrorFiles(p *Position) *Position {
+	mirrored := *p
+	for _, s := range AllSquares {
+		mirrored.SetPieceAt(Square{FileH + 1 - s.File, s.Rank}, p.PieceAt(s))
+	}
+	if p.EnPassant != NoSquare {
+		mirrored.EnPassant = Square{FileH + 1 - p.EnPassant.File, p.EnPassant.Rank}
+	}
+	return &mirrored
+}
+
+// legalEnPassantSquare returns the en passant square if an en passant capture is legal, and [NoSquare] otherwise.
+func legalEnPassantSquare(p *Position) Square {
+	if p.HasLegalEnPassant() {
+		return p.EnPassant
+	}
+	return NoSquare
+}
+
+// HasLegalEnPassant returns true if the side to move can legally capture en passant. A position can have an en passant
+// square without a legal capture, such as after any double pawn push with no pawn beside it. Repetition detection
+// and [Position.Key] only count the en passant square when this returns true.
+func (p *Position) HasLegalEnPassant() bool {
+	if p.EnPassant == NoSquare {
+		return false
+	}
+	captureRank := p.EnPassant.Rank - 1
+	if p.Turn == Black {
+		captureRank = p.EnPassant.Rank + 1
+	}
+	for _, from := range []Square{{p.EnPassant.File - 1, captureRank}, {p.EnPassant.File + 1, captureRank}} {
+		if !isValidSquare(from) || from.File == NoFile || p.PieceAt(from) != (Piece{p.Turn, Pawn}) {
+			continue
+		}
+		move := Move{from, p.EnPassant, NoPieceType}
+		if slices.Contains(generatePieceMoves(p, from), move) && len(FilterLegal(p, []Move{move})) == 1 {
+			return true
+		}
+	}
+	return false
+}
+
 func generateFenPos(p *Position) string {
 	fen := strings.Builder{}
 	currentFile := FileA
@@ -370,20 +529,43 @@ func generateFenTurn(p *Position) string {
 }
 
 func generateFenCastleRights(p *Position) string {
-	if !p.WhiteKingSideCastle && !p.WhiteQueenSideCastle && !p.BlackKingSideCastle && !p.BlackQueenSideCastle {
+	return p.CastlingRights().String()
+}
+
+// CastlingRights holds the castling rights of both sides, as stored in a [Position].
+type CastlingRights struct {
+	WhiteKing  bool
+	WhiteQueen bool
+	BlackKing  bool
+	BlackQueen bool
+}
+
+// CastlingRights returns the castling rights of p.
+func (p *Position) CastlingRights() CastlingRights {
+	return CastlingRights{
+		WhiteKing:  p.WhiteKingSideCastle,
+		WhiteQueen: p.WhiteQueenSideCastle,
+		BlackKing:  p.BlackKingSideCastle,
+		BlackQueen: p.BlackQueenSideCastle,
+	}
+}
+
+// String returns the castling rights as they are written in a FEN, such as "KQkq", or "-" if neither side can castle.
+func (cr CastlingRights) String() string {
+	if cr == (CastlingRights{}) {
 		return "-"
 	}
 	rights := ""
-	if p.WhiteKingSideCastle {
+	if cr.WhiteKing {
 		rights += "K"
 	}
-	if p.WhiteQueenSideCastle {
+	if cr.WhiteQueen {
 		rights += "Q"
 	}
-	if p.BlackKingSideCastle {
+	if cr.BlackKing {
 		rights += "k"
 	}
-	if p.BlackQueenSideCastle {
+	if cr.BlackQueen {
 		rights += "q"
 	}
 	return rights
@@ -468,6 +650,23 @@ func checkCastlingRightsLogical(p *Position) bool {
 	return true
 }
 
+// NormalizeCastlingRights removes any castling rights whose king or rook is not on its starting square. After calling it
+// the castling rights always pass the checks made by [IsValidPosition].
+func (p *Position) NormalizeCastlingRights() {
+	if p.PieceAt(E1) != WhiteKing || p.PieceAt(H1) != WhiteRook {
+		p.WhiteKingSideCastle = false
+	}
+	if p.PieceAt(E1) != WhiteKing || p.PieceAt(A1) != WhiteRook {
+		p.WhiteQueenSideCastle = false
+	}
+	if p.PieceAt(E8) != BlackKing || p.PieceAt(H8) != BlackRook {
+		p.BlackKingSideCastle = false
+	}
+	if p.PieceAt(E8) != BlackKing || p.PieceAt(A8) != BlackRook {
+		p.BlackQueenSideCastle = false
+	}
+}
+
 func checkEnPassantLogical(p *Position) bool {
 	if p.EnPassant == NoSquare {
 		return true
@@ -529,3 +728,103 @@ func findKing(p *Position, c Color) Square {
 	}
 	return NoSquare
 }
+
+// KingSquare returns the square of color c's king. The bool is false if c has no king on the board.
+func (p *Position) KingSquare(c Color) (Square, bool) {
+	s := findKing(p, c)
+	return s, s != NoSquare
+}
+
+// KingProximity returns the number of king moves between color c's king and target (see [ChebyshevDistance]). Returns
+// -1 if c has no king or target is not a valid square.
+func (p *Position) KingProximity(c Color, target Square) int {
+	kingSquare, ok := p.KingSquare(c)
+	if !ok || target == NoSquare || !isValidSquare(target) {
+		return -1
+	}
+	return int(ChebyshevDistance(kingSquare, target))
+}
+
+// MaterialSignature returns the standard name for the material on the board, such as "KRPvKR". White's pieces are
+// listed first, then "v", then black's pieces. Each side is ordered king, queen, rook, bishop, knight, pawn, with one
+// letter per piece.
+func (p *Position) MaterialSignature() string {
+	order := []PieceType{King, Queen, Rook, Bishop, Knight, Pawn}
+	counts := p.Counts()
+	signature := strings.Builder{}
+	for _, color := range []Color{White, Black} {
+		if color == Black {
+			signature.WriteRune('v')
+		}
+		for _, pieceType := range order {
+			signature.WriteString(strings.Repeat(pieceType.String(), counts[Piece{color, pieceType}]))
+		}
+	}
+	return signature.String()
+}
+
+// OpenFiles returns the files that have no pawns of either color on them, from the a-file to the h-file.
+func (p *Position) OpenFiles() []File {
+	open := []File{}
+	for _, f := range []File{FileA, FileB, FileC, FileD, FileE, FileF, FileG, FileH} {
+		if !p.hasPawnOnFile(f, White) && !p.hasPawnOnFile(f, Black) {
+			open = append(open, f)
+		}
+	}
+	return open
+}
+
+// HalfOpenFiles returns the files that have no pawns of color c but do have an opposing pawn, from the a-file to the
+// h-file.
+func (p *Position) HalfOpenFiles(c Color) []File {
+	halfOpen := []File{}
+	for _, f := range []File{FileA, FileB, FileC, FileD, FileE, FileF, FileG, FileH} {
+		if !p.hasPawnOnFile(f, c) && p.hasPawnOnFile(f, otherColor(c)) {
+			halfOpen = append(halfOpen, f)
+		}
+	}
+	return halfOpen
+}
+
+func (p *Position) hasPawnOnFile(f File, c Color) bool {
+	for r := Rank1; r <= Rank8; r++ {
+		if p.PieceAt(Square{f, r}) == (Piece{c, Pawn}) {
+			return true
+		}
+	}
+	return false
+}
+
+// Counts returns the number of each piece on the board, such as WhitePawn or BlackRook. Pieces that aren't on the board
+// are left out of the map, so looking them up gives 0.
+func (p *Position) Counts() map[Piece]int {
+	counts := map[Piece]int{}
+	for _, piece := range p.Board {
+		if piece != NoPiece {
+			counts[piece]++
+		}
+	}
+	return counts
+}
+
+// MaxGamePhase is the [Position.GamePhase] of the starting position.
+const MaxGamePhase = 24
+
+// GamePhase estimates how far the game is from the endgame, for tapering evaluations between middlegame and endgame
+// terms. Each knight and bishop on the board counts 1, each rook 2, and each queen 4, for both colors. Pawns and kings
+// don't count. The starting position gives [MaxGamePhase], and the total is clamped to it, so positions with extra
+// pieces from promotion still give MaxGamePhase. A position with only kings and pawns gives 0.
+func (p *Position) GamePhase() int {
+	phase := 0
+	for _, piece := range p.Board {
+		switch piece.Type {
+		case Knight, Bishop:
+			phase += 1
+		case Rook:
+			phase += 2
+		case Queen:
+			phase += 4
+		}
+	}
+	return min(phase, MaxGamePhase)
+}
@@ -1,6 +1,7 @@
 package chess
 
 import (
+	"strings"
 	"testing"
 )
 
@@ -452,3 +453,269 @@ func TestMovePromotion(t *testing.T) {
 		t.Errorf("incorrect result: move D6-D8Q: result %#v", pos)
 	}
 }
+
+func TestMoveInvalidPromotion(t *testing.T) {
+	position, _ := ParseFen("8/4P3/8/8/8/k7/8/K7 w - - 0 1")
+	expected := *position
+	position.Move(Move{E7, E8, King})
+	if *position != expected {
+		t.Errorf("incorrect result: input E7E8K: position should not have changed: expected %v, got %v", expected, *position)
+	}
+}
+
+func TestPositionKey(t *testing.T) {
+	position := getDefaultPosition()
+	if position.Key() != "rnbqkbnr/pppppppp/8/8/8/8/PPPPPPPP/RNBQKBNR w KQkq -" {
+		t.Errorf("incorrect result: default position: got %s", position.Key())
+	}
+
+	position.Move(Move{E2, E4, NoPieceType})
+	if position.Key() != "rnbqkbnr/pppppppp/8/8/4P3/8/PPPP1PPP/RNBQKBNR b KQkq -" {
+		t.Errorf("incorrect result: en passant not capturable: got %s", position.Key())
+	}
+
+	position, _ = ParseFen("rnbqkbnr/ppp1pppp/8/8/3pP3/8/PPPP1PPP/RNBQKBNR b KQkq e3 0 3")
+	if position.Key() != "rnbqkbnr/ppp1pppp/8/8/3pP3/8/PPPP1PPP/RNBQKBNR b KQkq e3" {
+		t.Errorf("incorrect result: en passant capturable: got %s", position.Key())
+	}
+
+	other := *position
+	other.HalfMove = 40
+	other.FullMove = 80
+	if position.Key() != other.Key() {
+		t.Errorf("incorrect result: keys should ignore move counters: %s != %s", position.Key(), other.Key())
+	}
+}
+
+func TestAfterMove(t *testing.T) {
+	position := getDefaultPosition()
+	after := position.AfterMove(Move{E2, E4, NoPieceType})
+	if *position != *getDefaultPosition() {
+		t.Error("AfterMove changed the original position")
+	}
+	expected, _ := ParseFen("rnbqkbnr/pppppppp/8/8/4P3/8/PPPP1PPP/RNBQKBNR b KQkq e3 0 1")
+	if *after != *expected {
+		t.Errorf("incorrect result: expected %s, got %s", GenerateFen(expected), GenerateFen(after))
+	}
+}
+
+func TestMaterialSignature(t *testing.T) {
+	tests := map[string]string{
+		DefaultFen:                           "KQRRBBNNPPPPPPPPvKQRRBBNNPPPPPPPP",
+		"8/8/8/4k3/8/8/8/3QK3 w - - 0 1":     "KQvK",
+		"8/8/3r4/4k3/8/4P3/8/3RK3 w - - 0 1": "KRPvKR",
+		"8/8/8/2n1k3/8/8/8/4K2B w - - 0 1":   "KBvKN",
+	}
+	for fen, expected := range tests {
+		position, _ := ParseFen(fen)
+		if signature := position.MaterialSignature(); signature != expected {
+			t.Errorf("incorrect result: input %s: expected %s, got %s", fen, expected, signature)
+		}
+	}
+}
+
+func TestKeyEnPassantAndCastling(t *testing.T) {
+	position1, _ := ParseFen("rnbqkbnr/pppppppp/8/8/4P3/8/PPPP1PPP/RNBQKBNR b KQkq e3 0 1")
+	position2, _ := ParseFen("rnbqkbnr/pppppppp/8/8/4P3/8/PPPP1PPP/RNBQKBNR b KQkq - 5 9")
+	if position1.Key() != position2.Key() {
+		t.Errorf("incorrect result: expected equal strings, got %s and %s", position1.Key(), position2.Key())
+	}
+	if !positionsEqualNoMoveCounter(position1, position2) {
+		t.Error("incorrect result: positions with an uncapturable en passant square should be equal")
+	}
+	position2.WhiteKingSideCastle = false
+	if position1.Key() == position2.Key() || positionsEqualNoMoveCounter(position1, position2) {
+		t.Error("incorrect result: positions with different castling rights should not be equal")
+	}
+	if position1.EqualityFEN() != position1.Key() {
+		t.Errorf("incorrect result: EqualityFEN should match Key, got %s and %s", position1.EqualityFEN(), position1.Key())
+	}
+}
+
+func TestNormalizeCastlingRights(t *testing.T) {
+	position, _ := ParseFen("r3k3/8/8/8/8/8/8/4K2R w KQkq - 0 1")
+	if IsValidPosition(position) {
+		t.Fatal("position with impossible castling rights should not be valid")
+	}
+	position.NormalizeCastlingRights()
+	if generateFenCastleRights(position) != "Kq" {
+		t.Errorf("incorrect result: expected Kq, got %s", generateFenCastleRights(position))
+	}
+	if !IsValidPosition(position) {
+		t.Error("incorrect result: normalized position should be valid")
+	}
+}
+
+func TestKingSquare(t *testing.T) {
+	pos, _ := ParseFen("8/8/4k3/8/8/8/8/6K1 w - - 0 1")
+	if s, ok := pos.KingSquare(White); !ok || s != G1 {
+		t.Errorf("incorrect result: input White: expected G1 true, got %v %v", s, ok)
+	}
+	if s, ok := pos.KingSquare(Black); !ok || s != E6 {
+		t.Errorf("incorrect result: input Black: expected E6 true, got %v %v", s, ok)
+	}
+	pos.SetPieceAt(G1, NoPiece)
+	if s, ok := pos.KingSquare(White); ok || s != NoSquare {
+		t.Errorf("incorrect result: no white king: expected NoSquare false, got %v %v", s, ok)
+	}
+}
+
+func TestKingProximity(t *testing.T) {
+	pos, _ := ParseFen("8/8/4k3/8/8/8/8/6K1 w - - 0 1")
+	if d := pos.KingProximity(White, A7); d != 6 {
+		t.Errorf("incorrect result: input White A7: expected 6, got %d", d)
+	}
+	if d := pos.KingProximity(Black, E1); d != 5 {
+		t.Errorf("incorrect result: input Black E1: expected 5, got %d", d)
+	}
+	if d := pos.KingProximity(Black, NoSquare); d != -1 {
+		t.Errorf("incorrect result: input Black NoSquare: expected -1, got %d", d)
+	}
+}
+
+func TestCanonicalKey(t *testing.T) {
+	pos := getDefaultPosition()
+	if pos.CanonicalKey() != pos.Key() {
+		t.Errorf("incorrect result: start position: expected %s, got %s", pos.Key(), pos.CanonicalKey())
+	}
+
+	pos1, _ := ParseFen("4k3/8/8/8/8/8/1P6/4K3 w - - 0 1")
+	pos2, _ := ParseFen("3k4/8/8/8/8/8/6P1/3K4 w - - 0 1")
+	if pos1.CanonicalKey() != pos2.CanonicalKey() {
+		t.Errorf("incorrect result: mirrored positions: expected equal keys, got %s and %s", pos1.CanonicalKey(), pos2.CanonicalKey())
+	}
+	if pos1.CanonicalKey() != min(pos1.Key(), pos2.Key()) {
+		t.Errorf("incorrect result: expected the smaller key, got %s", pos1.CanonicalKey())
+	}
+
+	pos3, _ := ParseFen("4k3/8/8/8/8/8/1P6/4K2R w K - 0 1")
+	if pos3.CanonicalKey() != pos3.Key() {
+		t.Errorf("incorrect result: position with castling rights: expected %s, got %s", pos3.Key(), pos3.CanonicalKey())
+	}
+}
+
+func TestCounts(t *testing.T) {
+	pos := getDefaultPosition()
+	counts := pos.Counts()
+	if counts[WhitePawn] != 8 || counts[BlackKnight] != 2 || counts[WhiteQueen] != 1 || len(counts) != 12 {
+		t.Errorf("incorrect result: start position: got %v", counts)
+	}
+	pos, _ = ParseFen("4k3/8/8/8/8/8/4P3/4K3 w - - 0 1")
+	counts = pos.Counts()
+	if counts[WhitePawn] != 1 || counts[BlackPawn] != 0 || len(counts) != 3 {
+		t.Errorf("incorrect result: KPvK: got %v", counts)
+	}
+}
+
+func TestGamePhase(t *testing.T) {
+	testCases := []struct {
+		fen      string
+		expected int
+	}{
+		{DefaultFen, MaxGamePhase},
+		{"4k3/pppppppp/8/8/8/8/PPPPPPPP/4K3 w - - 0 1", 0},
+		{"r3k3/8/8/8/8/8/8/2B1KQ2 w - - 0 1", 7},
+		{"1nbqkbn1/8/8/8/8/8/8/3QK3 w - - 0 1", 12},
+		{"qqqqkqqq/8/8/8/8/8/8/QQQQKQQQ w - - 0 1", MaxGamePhase},
+	}
+	for _, tc := range testCases {
+		pos, _ := ParseFen(tc.fen)
+		if phase := pos.GamePhase(); phase != tc.expected {
+			t.Errorf("incorrect result: input %s: expected %d, got %d", tc.fen, tc.expected, phase)
+		}
+	}
+}
+
+func TestFormatStringANSI(t *testing.T) {
+	pos := getDefaultPosition()
+	str := pos.FormatStringANSI(false)
+	lines := strings.Split(str, "\n")
+	if len(lines) != 9 {
+		t.Fatalf("incorrect result: expected 9 lines, got %d:\n%s", len(lines), str)
+	}
+	if !strings.HasPrefix(lines[0], "8 "+ansiLightSquare+ansiBlackPiece+" \u265c ") {
+		t.Errorf("incorrect result: expected rank 8 to start with a black rook on a light square, got %q", lines[0])
+	}
+	if !strings.HasSuffix(lines[7], ansiWhitePiece+" \u265c "+ansiReset) {
+		t.Errorf("incorrect result: expected rank 1 to end with a white rook, got %q", lines[7])
+	}
+	if lines[8] != "   a  b  c  d  e  f  g  h " {
+		t.Errorf("incorrect result: unexpected file labels %q", lines[8])
+	}
+
+	flipped := strings.Split(pos.FormatStringANSI(true), "\n")
+	if !strings.HasPrefix(flipped[0], "1 ") || flipped[8] != "   h  g  f  e  d  c  b  a " {
+		t.Errorf("incorrect result: black's perspective: got %q and %q", flipped[0], flipped[8])
+	}
+}
+
+func TestOpenFiles(t *testing.T) {
+	pos, _ := ParseFen("r3k3/ppp2ppp/8/4p3/8/8/PPP2PPP/3RK3 w - - 0 1")
+	if files := pos.OpenFiles(); len(files) != 1 || files[0] != FileD {
+		t.Errorf("incorrect result: expected [D], got %v", files)
+	}
+	if files := pos.HalfOpenFiles(White); len(files) != 1 || files[0] != FileE {
+		t.Errorf("incorrect result: White: expected [E], got %v", files)
+	}
+	if files := pos.HalfOpenFiles(Black); len(files) != 0 {
+		t.Errorf("incorrect result: Black: expected [], got %v", files)
+	}
+}
+
+func TestParseFenDuplicateCastlingRights(t *testing.T) {
+	pos, err := ParseFen("r3k2r/8/8/8/8/8/8/R3K2R w KKqq - 0 1")
+	if err != nil {
+		t.Fatalf("incorrect result: expected nil error, got %v", err)
+	}
+	if !pos.WhiteKingSideCastle || pos.WhiteQueenSideCastle || pos.BlackKingSideCastle || !pos.BlackQueenSideCastle {
+		t.Errorf("incorrect result: expected Kq rights, got %s", generateFenCastleRights(pos))
+	}
+
+	pos, err = ParsePositionLenient("r3k2r/8/8/8/8/8/8/R3K2R w KKqq - 0 1")
+	if err != nil {
+		t.Fatalf("incorrect result: lenient: expected nil error, got %v", err)
+	}
+	if GenerateFen(pos) != "r3k2r/8/8/8/8/8/8/R3K2R w Kq - 0 1" {
+		t.Errorf("incorrect result: lenient: expected duplicate rights to be dropped, got %s", GenerateFen(pos))
+	}
+	pos, err = ParsePositionLenient("r3k2r/8/8/8/8/8/8/R3K2R w KQkqKQkq - 0 1")
+	if err != nil || GenerateFen(pos) != "r3k2r/8/8/8/8/8/8/R3K2R w KQkq - 0 1" {
+		t.Errorf("incorrect result: lenient: expected KQkq, got %s %v", GenerateFen(pos), err)
+	}
+	if _, err := ParsePositionLenient("r3k2r/8/8/8/8/8/8/R3K2R w KX - 0 1"); err == nil {
+		t.Error("incorrect result: lenient: invalid letter: expected error, got nil")
+	}
+}
+
+func TestCastlingRights(t *testing.T) {
+	pos := getDefaultPosition()
+	if cr := pos.CastlingRights(); cr != (CastlingRights{true, true, true, true}) || cr.String() != "KQkq" {
+		t.Errorf("incorrect result: start position: expected KQkq, got %v", cr)
+	}
+	pos, _ = ParseFen("r3k2r/8/8/8/8/8/8/R3K2R w Qk - 0 1")
+	if cr := pos.CastlingRights(); cr != (CastlingRights{WhiteQueen: true, BlackKing: true}) || cr.String() != "Qk" {
+		t.Errorf("incorrect result: input Qk: got %v", cr)
+	}
+	if s := (CastlingRights{}).String(); s != "-" {
+		t.Errorf("incorrect result: no rights: expected -, got %s", s)
+	}
+}
+
+func TestHasLegalEnPassant(t *testing.T) {
+	testCases := []struct {
+		fen      string
+		expected bool
+	}{
+		{"rnbqkbnr/pppppppp/8/8/4P3/8/PPPP1PPP/RNBQKBNR b KQkq e3 0 1", false},
+		{"rnbqkbnr/ppp1p1pp/8/3pPp2/8/8/PPPP1PPP/RNBQKBNR w KQkq f6 0 3", true},
+		{"8/8/8/KPp4r/8/8/8/7k w - c6 0 1", false},
+		{"8/8/8/8/3pP3/8/8/k3K3 b - e3 0 1", true},
+		{"8/8/8/8/Pp6/8/8/k3K3 b - a3 0 1", true},
+	}
+	for _, tc := range testCases {
+		pos, _ := ParseFen(tc.fen)
+		if pos.HasLegalEnPassant() != tc.expected {
+			t.Errorf("incorrect result: input %s: expected %v, got %v", tc.fen, tc.expected, !tc.expected)
+		}
+	}
+}
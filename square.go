@@ -157,6 +157,34 @@ var (
 	}
 )
 
+var (
+	// LightSquares contains every light square, ordered from A8 to H1 like [AllSquares].
+	LightSquares = squaresOfShade(true)
+	// DarkSquares contains every dark square, ordered from A8 to H1 like [AllSquares].
+	DarkSquares = squaresOfShade(false)
+)
+
+func squaresOfShade(light bool) [32]Square {
+	squares := [32]Square{}
+	index := 0
+	for _, square := range AllSquares {
+		if square.IsLight() == light {
+			squares[index] = square
+			index++
+		}
+	}
+	return squares
+}
+
+// IsLight returns true if s is a light square. H1 and A8 are light, A1 and H8 are dark. Returns false for [NoSquare] and
+// invalid squares.
+func (s Square) IsLight() bool {
+	if !isValidSquare(s) || s == NoSquare {
+		return false
+	}
+	return (s.File+File(s.Rank))%2 == 1
+}
+
 // String returns a two letter uppercase representation of a square. "-" will be returned for [NoSquare] and INVALID SQUARE will be returned for all other squares that can't be found on a chess board.
 func (s Square) String() string {
 	if !isValidSquare(s) {
@@ -245,6 +273,74 @@ func squareBelow(s Square) Square {
 	return s
 }
 
+// KingRing returns the squares a king on s attacks, ordered from A8 to H1 like [AllSquares]. There are 8 squares, or
+// fewer if s is on the edge of the board. An empty slice is returned if s is not a valid square.
+func KingRing(s Square) []Square {
+	ring := []Square{}
+	if s == NoSquare || !isValidSquare(s) {
+		return ring
+	}
+	for _, square := range AllSquares {
+		if ChebyshevDistance(s, square) == 1 {
+			ring = append(ring, square)
+		}
+	}
+	return ring
+}
+
+// SquaresBetween returns the squares strictly between a and b when they share a rank, file, or diagonal, ordered from a
+// to b. An empty slice is returned if they aren't aligned, are adjacent, are the same square, or either is invalid.
+// Results are looked up in a table built when the package is initialized, so the returned slice is shared and must not
+// be modified.
+func SquaresBetween(a Square, b Square) []Square {
+	if a == NoSquare || b == NoSquare || !isValidSquare(a) || !isValidSquare(b) {
+		return []Square{}
+	}
+	return squaresBetweenTable[squareToIndex(a)][squareToIndex(b)]
+}
+
+var squaresBetweenTable = buildSquaresBetweenTable()
+
+func buildSquaresBetweenTable() [64][64][]Square {
+	table := [64][64][]Square{}
+	for _, a := range AllSquares {
+		for _, b := range AllSquares {
+			between := squaresBetween(a, b)
+			table[squareToIndex(a)][squareToIndex(b)] = between[:len(between):len(between)]
+		}
+	}
+	return table
+}
+
+// squaresBetween computes the result of [SquaresBetween] without using the table.
+func squaresBetween(a Square, b Square) []Square {
+	between := []Square{}
+	if a == NoSquare || b == NoSquare || !isValidSquare(a) || !isValidSquare(b) || a == b {
+		return between
+	}
+	fileStep := stepToward(int(a.File), int(b.File))
+	rankStep := stepToward(int(a.Rank), int(b.Rank))
+	fileDistance := int(b.File) - int(a.File)
+	rankDistance := int(b.Rank) - int(a.Rank)
+	if fileDistance != 0 && rankDistance != 0 && fileDistance*fileStep != rankDistance*rankStep {
+		return between
+	}
+	for s := (Square{File(int(a.File) + fileStep), Rank(int(a.Rank) + rankStep)}); s != b; s = (Square{File(int(s.File) + fileStep), Rank(int(s.Rank) + rankStep)}) {
+		between = append(between, s)
+	}
+	return between
+}
+
+func stepToward(from int, to int) int {
+	if from < to {
+		return 1
+	}
+	if from > to {
+		return -1
+	}
+	return 0
+}
+
 // ChebyshevDistance returns the number of king moves between two squares. Returns [math.MaxUint8] if either square is invalid.
 func ChebyshevDistance(s1 Square, s2 Square) uint8 {
 	if !isValidSquare(s1) || !isValidSquare(s2) {
@@ -262,7 +358,7 @@ func ChebyshevDistance(s1 Square, s2 Square) uint8 {
 	} else {
 		rankDistance = uint8(s2.Rank) - uint8(s1.Rank)
 	}
-	return min(fileDistance, rankDistance)
+	return max(fileDistance, rankDistance)
 }
 
 // ManhattanDistance give the number of non-diagonal king moves to get from s1 to s2. Returns [math.MaxUint8] if either square is not a valid chess square.
@@ -2,6 +2,7 @@ package chess
 
 import (
 	"math"
+	"slices"
 	"testing"
 )
 
@@ -74,14 +75,14 @@ func TestChebyshevDistance(t *testing.T) {
 	if ChebyshevDistance(A1, H8) != 7 {
 		t.Errorf("A1 and H8 should be 7")
 	}
-	if ChebyshevDistance(A1, H7) != 6 {
-		t.Errorf("A1 and H7 should be 6")
+	if ChebyshevDistance(A1, H7) != 7 {
+		t.Errorf("A1 and H7 should be 7")
 	}
 	if ChebyshevDistance(H8, A1) != 7 {
 		t.Errorf("H8 and A1 should be 7")
 	}
-	if ChebyshevDistance(H7, A1) != 6 {
-		t.Errorf("H7 and A1 should be 6")
+	if ChebyshevDistance(H7, A1) != 7 {
+		t.Errorf("H7 and A1 should be 7")
 	}
 	if ChebyshevDistance(A1, Square{100, 9}) != math.MaxUint8 {
 		t.Errorf("Invalid s2 did not give math.MaxUint8")
@@ -111,3 +112,66 @@ func TestManhattanDistance(t *testing.T) {
 		t.Errorf("Invalid s2 did not give math.MaxUint8")
 	}
 }
+
+func TestSquareIsLight(t *testing.T) {
+	if !H1.IsLight() || !A8.IsLight() || !D1.IsLight() {
+		t.Error("incorrect result: H1, A8, and D1 should be light")
+	}
+	if A1.IsLight() || H8.IsLight() || E1.IsLight() {
+		t.Error("incorrect result: A1, H8, and E1 should be dark")
+	}
+	if NoSquare.IsLight() {
+		t.Error("incorrect result: NoSquare should not be light")
+	}
+}
+
+func TestLightAndDarkSquares(t *testing.T) {
+	for _, square := range AllSquares {
+		inLight := slices.Contains(LightSquares[:], square)
+		inDark := slices.Contains(DarkSquares[:], square)
+		if inLight == inDark {
+			t.Errorf("incorrect result: %v should be in exactly one of LightSquares and DarkSquares", square)
+		}
+		if inLight != square.IsLight() {
+			t.Errorf("incorrect result: %v: LightSquares disagrees with IsLight", square)
+		}
+	}
+}
+
+func TestKingRing(t *testing.T) {
+	ring := KingRing(E4)
+	expected := []Square{D5, E5, F5, D4, F4, D3, E3, F3}
+	if !slices.Equal(ring, expected) {
+		t.Errorf("incorrect result: input E4: expected %v, got %v", expected, ring)
+	}
+	ring = KingRing(A1)
+	expected = []Square{A2, B2, B1}
+	if !slices.Equal(ring, expected) {
+		t.Errorf("incorrect result: input A1: expected %v, got %v", expected, ring)
+	}
+	if ring := KingRing(NoSquare); len(ring) != 0 {
+		t.Errorf("incorrect result: input NoSquare: expected [], got %v", ring)
+	}
+}
+
+func TestSquaresBetween(t *testing.T) {
+	testCases := []struct {
+		a, b     Square
+		expected []Square
+	}{
+		{A1, A4, []Square{A2, A3}},
+		{H8, A1, []Square{G7, F6, E5, D4, C3, B2}},
+		{E4, B4, []Square{D4, C4}},
+		{C1, A3, []Square{B2}},
+		{E4, E5, []Square{}},
+		{A1, B3, []Square{}},
+		{E4, E4, []Square{}},
+		{E4, NoSquare, []Square{}},
+	}
+	for _, tc := range testCases {
+		between := SquaresBetween(tc.a, tc.b)
+		if !slices.Equal(between, tc.expected) {
+			t.Errorf("incorrect result: input %v %v: expected %v, got %v", tc.a, tc.b, tc.expected, between)
+		}
+	}
+}